@@ -0,0 +1,593 @@
+package shippinglabel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// AddressImportOptions configures ImportVCards and ImportAddressCSV
+type AddressImportOptions struct {
+	AddressType AddressTypeCode // Type of the imported addresses
+	Similarity  float64         // Minimum similarity (0-1) of name and street of duplicates. Default: 0.85
+}
+
+// AddressDuplicate is an address which was removed as duplicate
+type AddressDuplicate struct {
+	Address    *Address
+	Of         *Address // Kept address
+	Similarity float64
+}
+
+// AddressImportResult contains the normalized addresses without duplicates, e.g. for CreateAddress or SyncAddresses
+type AddressImportResult struct {
+	Addresses  []*Address
+	Duplicates []*AddressDuplicate
+}
+
+// ImportVCards parses vCard 3.0 and 4.0 files, normalizes the addresses and removes duplicates. opts can be nil.
+func ImportVCards(r io.Reader, opts *AddressImportOptions) (*AddressImportResult, error) {
+	addresses, err := ParseVCards(r)
+	if err != nil {
+		return nil, err
+	}
+	return prepareImport(addresses, opts), nil
+}
+
+// ImportAddressCSV parses a CSV export with a known layout (see AddressCSVLayouts), normalizes the addresses and
+// removes duplicates. opts can be nil.
+func ImportAddressCSV(r io.Reader, opts *AddressImportOptions) (*AddressImportResult, error) {
+	addresses, err := DecodeAddressCSV(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return prepareImport(addresses, opts), nil
+}
+
+func prepareImport(addresses []*Address, opts *AddressImportOptions) *AddressImportResult {
+	o := AddressImportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Similarity <= 0 {
+		o.Similarity = 0.85
+	}
+
+	for _, a := range addresses {
+		NormalizeAddress(a)
+		if o.AddressType != "" {
+			a.AddressType = o.AddressType
+		}
+	}
+
+	res := &AddressImportResult{}
+	res.Addresses, res.Duplicates = DedupeAddresses(addresses, o.Similarity)
+	return res
+}
+
+// VCARD
+
+// vCard is a contact of a vCard file
+type vCard struct {
+	family, given, fn, org string
+	tel, mail              string
+	telPref, mailPref      bool
+	adrs                   [][]string
+}
+
+// ParseVCards parses vCard 3.0 and 4.0 (and quoted-printable 2.1) files. Every ADR property of a contact is returned as
+// an address with the name, organization, phone number and mail of the contact. Contacts without ADR are skipped.
+func ParseVCards(r io.Reader) ([]*Address, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, _, err = decodeText(b); err != nil {
+		return nil, err
+	}
+
+	addresses := make([]*Address, 0)
+	var card *vCard
+	for i, line := range unfoldVCard(b) {
+		name, params, value := splitVCardLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			card = &vCard{}
+		case name == "END" && strings.EqualFold(value, "VCARD"):
+			if card == nil {
+				return nil, fmt.Errorf("%w: line %d: END without BEGIN", ErrInvalidVCard, i+1)
+			}
+			addresses = append(addresses, card.addresses()...)
+			card = nil
+		case card != nil:
+			if strings.EqualFold(params["ENCODING"], "QUOTED-PRINTABLE") {
+				value = decodeQuotedPrintable(value, params["CHARSET"])
+			}
+			card.set(name, params, value)
+		}
+	}
+
+	if card != nil {
+		return nil, fmt.Errorf("%w: missing END:VCARD", ErrInvalidVCard)
+	}
+	return addresses, nil
+}
+
+// decodeQuotedPrintable decodes a quoted-printable vCard value in the charset of the property (e.g. ISO-8859-1 in
+// vCard 2.1 exports). Values without a known charset are decoded as UTF-8, or as ISO-8859-1 if they are not valid
+// UTF-8.
+func decodeQuotedPrintable(value string, charset string) string {
+	b, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(value)))
+	if err != nil {
+		return value
+	}
+
+	if enc, err := htmlindex.Get(charset); err == nil {
+		if decoded, err := enc.NewDecoder().Bytes(b); err == nil {
+			return string(decoded)
+		}
+	}
+
+	if b, _, err = decodeText(b); err != nil {
+		return value
+	}
+	return string(b)
+}
+
+func (m *vCard) set(name string, params map[string]string, value string) {
+	pref := strings.Contains(strings.ToUpper(params["TYPE"]), "PREF") || params["PREF"] != ""
+	switch name {
+	case "N":
+		c := splitVCardValue(value)
+		m.family, m.given = vCardComponent(c, 0), vCardComponent(c, 1)
+	case "FN":
+		m.fn = unescapeVCard(value)
+	case "ORG":
+		m.org = vCardComponent(splitVCardValue(value), 0)
+	case "TEL":
+		if m.tel == "" || (pref && !m.telPref) {
+			m.tel, m.telPref = strings.TrimPrefix(unescapeVCard(value), "tel:"), pref
+		}
+	case "EMAIL":
+		if m.mail == "" || (pref && !m.mailPref) {
+			m.mail, m.mailPref = strings.TrimPrefix(unescapeVCard(value), "mailto:"), pref
+		}
+	case "ADR":
+		m.adrs = append(m.adrs, splitVCardValue(value))
+	}
+}
+
+// addresses converts the ADR properties (PO box; extended address; street; locality; region; postal code; country)
+func (m *vCard) addresses() []*Address {
+	res := make([]*Address, 0, len(m.adrs))
+	for _, adr := range m.adrs {
+		a := &Address{
+			Company:    m.org,
+			FirstName:  m.given,
+			LastName:   m.family,
+			City:       vCardComponent(adr, 3),
+			State:      vCardComponent(adr, 4),
+			PostalCode: vCardComponent(adr, 5),
+			Country:    vCardComponent(adr, 6),
+			Phone:      m.tel,
+			Mail:       m.mail,
+		}
+
+		if a.FirstName == "" && a.LastName == "" && m.fn != m.org {
+			a.LastName = m.fn
+		}
+
+		// Additional street lines are moved into the address addition
+		lines := strings.Split(vCardComponent(adr, 2), "\n")
+		a.Street = strings.TrimSpace(lines[0])
+		addition := append(lines[1:], vCardComponent(adr, 1), vCardComponent(adr, 0))
+		a.AddressAddition = joinNonEmpty(addition, ", ")
+		res = append(res, a)
+	}
+	return res
+}
+
+// unfoldVCard splits the file into lines and joins folded lines (lines starting with a space or a tab)
+func unfoldVCard(b []byte) []string {
+	lines := make([]string, 0)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	qpSoftBreak := false
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		switch {
+		case len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			lines[len(lines)-1] += line[1:]
+		case len(lines) > 0 && qpSoftBreak:
+			lines[len(lines)-1] += line
+		case line != "":
+			lines = append(lines, line)
+		}
+
+		// Quoted-printable lines ending with = continue on the next line
+		qpSoftBreak = len(lines) > 0 && strings.Contains(strings.ToUpper(lines[len(lines)-1]), "QUOTED-PRINTABLE") &&
+			strings.HasSuffix(lines[len(lines)-1], "=")
+		if qpSoftBreak {
+			lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], "=")
+		}
+	}
+	return lines
+}
+
+// splitVCardLine splits a content line (group.NAME;PARAM=VALUE:value) into the uppercase name, the parameters and the
+// value
+func splitVCardLine(line string) (name string, params map[string]string, value string) {
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+
+	if colon < 0 {
+		return "", nil, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	name = strings.ToUpper(parts[0])
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	params = make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			// vCard 2.1 parameters without name (e.g. TEL;CELL;PREF)
+			k, v = "TYPE", p
+		}
+
+		k = strings.ToUpper(k)
+		v = strings.Trim(v, `"`)
+		if params[k] != "" {
+			v = params[k] + "," + v
+		}
+		params[k] = v
+	}
+	return name, params, line[colon+1:]
+}
+
+// splitVCardValue splits a structured value at unescaped semicolons and unescapes the components
+func splitVCardValue(value string) []string {
+	res := make([]string, 0)
+	cur := strings.Builder{}
+	escaped := false
+	for _, c := range value {
+		switch {
+		case escaped:
+			cur.WriteRune('\\')
+			cur.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == ';':
+			res = append(res, unescapeVCard(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteRune(c)
+		}
+	}
+	return append(res, unescapeVCard(cur.String()))
+}
+
+var vCardUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeVCard(s string) string {
+	return strings.TrimSpace(vCardUnescaper.Replace(s))
+}
+
+func vCardComponent(components []string, i int) string {
+	if i >= len(components) {
+		return ""
+	}
+	return strings.TrimSpace(components[i])
+}
+
+func joinNonEmpty(values []string, sep string) string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return strings.Join(res, sep)
+}
+
+// CSV
+
+// AddressCSVLayout maps the header fields of a CSV export to Address fields
+type AddressCSVLayout struct {
+	Name string
+	// Address field -> header fields. The first non-empty header field is used. The first mapping with a street or
+	// a city is used for a row (e.g. the business or the home address of Outlook).
+	Mappings []map[string][]string
+}
+
+func outlookMapping(street, street2, city, state, postalCode, country string) map[string][]string {
+	return map[string][]string{
+		"FirstName": {"First Name"}, "LastName": {"Last Name"}, "Company": {"Company"},
+		"Street": {street}, "AddressAddition": {street2}, "City": {city}, "State": {state},
+		"PostalCode": {postalCode}, "Country": {country},
+		"Mail": {"E-mail Address"}, "Phone": {"Business Phone", "Mobile Phone", "Home Phone"},
+	}
+}
+
+func outlookMappingDE(street, street2, city, state, postalCode, country string) map[string][]string {
+	return map[string][]string{
+		"FirstName": {"Vorname"}, "LastName": {"Nachname"}, "Company": {"Firma"},
+		"Street": {street}, "AddressAddition": {street2}, "City": {city}, "State": {state},
+		"PostalCode": {postalCode}, "Country": {country},
+		"Mail": {"E-Mail-Adresse"}, "Phone": {"Telefon geschäftlich", "Mobiltelefon", "Telefon privat"},
+	}
+}
+
+func woocommerceMapping(prefix string) map[string][]string {
+	return map[string][]string{
+		"FirstName": {prefix + "_first_name"}, "LastName": {prefix + "_last_name"}, "Company": {prefix + "_company"},
+		"Street": {prefix + "_address_1"}, "AddressAddition": {prefix + "_address_2"}, "City": {prefix + "_city"},
+		"State": {prefix + "_state"}, "PostalCode": {prefix + "_postcode"}, "Country": {prefix + "_country"},
+		"Mail": {prefix + "_email", "billing_email"}, "Phone": {prefix + "_phone", "billing_phone"},
+	}
+}
+
+// AddressCSVLayouts are the layouts which are detected by DecodeAddressCSV
+var AddressCSVLayouts = []*AddressCSVLayout{
+	{Name: "Outlook", Mappings: []map[string][]string{
+		outlookMapping("Business Street", "Business Street 2", "Business City", "Business State", "Business Postal Code", "Business Country/Region"),
+		outlookMapping("Home Street", "Home Street 2", "Home City", "Home State", "Home Postal Code", "Home Country/Region"),
+		outlookMapping("Other Street", "Other Street 2", "Other City", "Other State", "Other Postal Code", "Other Country/Region"),
+	}},
+	{Name: "Outlook (DE)", Mappings: []map[string][]string{
+		outlookMappingDE("Straße geschäftlich", "Straße geschäftlich 2", "Ort geschäftlich", "Region geschäftlich", "Postleitzahl geschäftlich", "Land/Region geschäftlich"),
+		outlookMappingDE("Straße privat", "Straße privat 2", "Ort privat", "Region privat", "Postleitzahl privat", "Land/Region privat"),
+		outlookMappingDE("Weitere Straße", "Weitere Straße 2", "Weiterer Ort", "Weitere Region", "Weitere Postleitzahl", "Weiteres Land/Region"),
+	}},
+	{Name: "Google Contacts", Mappings: []map[string][]string{{
+		"FirstName": {"First Name", "Given Name"}, "LastName": {"Last Name", "Family Name"},
+		"Company": {"Organization Name", "Organization 1 - Name"},
+		"Street":  {"Address 1 - Street"}, "AddressAddition": {"Address 1 - Extended Address", "Address 1 - PO Box"},
+		"City": {"Address 1 - City"}, "State": {"Address 1 - Region"}, "PostalCode": {"Address 1 - Postal Code"},
+		"Country": {"Address 1 - Country"}, "Mail": {"E-mail 1 - Value"}, "Phone": {"Phone 1 - Value"},
+	}}},
+	{Name: "Shopify", Mappings: []map[string][]string{{
+		"FirstName": {"First Name", "Default Address First Name"}, "LastName": {"Last Name", "Default Address Last Name"},
+		"Company":         {"Company", "Default Address Company"},
+		"Street":          {"Address1", "Default Address Address1"},
+		"AddressAddition": {"Address2", "Default Address Address2"},
+		"City":            {"City", "Default Address City"},
+		"State":           {"Province Code", "Default Address Province Code"},
+		"PostalCode":      {"Zip", "Default Address Zip"},
+		"Country":         {"Country Code", "Default Address Country Code"},
+		"Mail":            {"Email"}, "Phone": {"Phone", "Default Address Phone"},
+	}}},
+	{Name: "WooCommerce", Mappings: []map[string][]string{woocommerceMapping("shipping"), woocommerceMapping("billing")}},
+}
+
+// DetectAddressCSVLayout returns the layout with the most matching header fields. At least three header fields must
+// match.
+func DetectAddressCSVLayout(header []string) (*AddressCSVLayout, bool) {
+	columns := make(map[string]bool, len(header))
+	for _, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+
+	var best *AddressCSVLayout
+	bestScore := 2
+	for _, l := range AddressCSVLayouts {
+		seen := make(map[string]bool)
+		for _, m := range l.Mappings {
+			for _, headers := range m {
+				for _, h := range headers {
+					if columns[strings.ToLower(h)] {
+						seen[strings.ToLower(h)] = true
+					}
+				}
+			}
+		}
+
+		if len(seen) > bestScore {
+			best, bestScore = l, len(seen)
+		}
+	}
+	return best, best != nil
+}
+
+// DecodeAddressCSV parses a CSV export into addresses. The encoding and the delimiter are detected. If layout is nil,
+// the layout is detected with DetectAddressCSVLayout.
+func DecodeAddressCSV(r io.Reader, layout *AddressCSVLayout) ([]*Address, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, _, err = decodeText(b); err != nil {
+		return nil, err
+	}
+
+	comma, _ := sniffDelimiter(b)
+	cr := csv.NewReader(bytes.NewReader(b))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrMissingCSVHeader
+		}
+		return nil, err
+	}
+
+	if layout == nil {
+		var ok bool
+		if layout, ok = DetectAddressCSVLayout(header); !ok {
+			return nil, ErrUnknownAddressLayout
+		}
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	addresses := make([]*Address, 0)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if isEmptyRecord(record) {
+			continue
+		}
+
+		if a := layout.decode(record, columns); a != nil {
+			addresses = append(addresses, a)
+		}
+	}
+	return addresses, nil
+}
+
+// decode returns the address of the first mapping with a street or a city
+func (m *AddressCSVLayout) decode(record []string, columns map[string]int) *Address {
+	value := func(headers []string) string {
+		for _, h := range headers {
+			if i, ok := columns[strings.ToLower(h)]; ok && i < len(record) && strings.TrimSpace(record[i]) != "" {
+				return strings.TrimSpace(record[i])
+			}
+		}
+		return ""
+	}
+
+	for _, mapping := range m.Mappings {
+		if value(mapping["Street"]) == "" && value(mapping["City"]) == "" {
+			continue
+		}
+
+		a := &Address{}
+		v := reflect.ValueOf(a).Elem()
+		for field, headers := range mapping {
+			if idx := fieldIndex(v.Type(), field); idx >= 0 {
+				v.Field(idx).SetString(value(headers))
+			}
+		}
+		return a
+	}
+	return nil
+}
+
+// DEDUPLICATION
+
+var streetAbbreviationRegexp = regexp.MustCompile(`str\b`)
+
+// DedupeAddresses removes fuzzy duplicates: addresses with the same country, postal code and house number whose name and
+// street have at least the similarity (0-1). Empty fields of the kept address are filled from its duplicates.
+func DedupeAddresses(addresses []*Address, similarity float64) ([]*Address, []*AddressDuplicate) {
+	unique := make([]*Address, 0, len(addresses))
+	duplicates := make([]*AddressDuplicate, 0)
+	byLocation := make(map[string][]*Address)
+	for _, a := range addresses {
+		country, err := NormalizeCountry(a.Country)
+		if err != nil {
+			country = strings.ToUpper(strings.TrimSpace(a.Country))
+		}
+
+		key := country + "|" + strings.ToUpper(strings.ReplaceAll(a.PostalCode, " ", ""))
+		var dup *AddressDuplicate
+		for _, kept := range byLocation[key] {
+			if s := addressSimilarity(a, kept); s >= similarity && (dup == nil || s > dup.Similarity) {
+				dup = &AddressDuplicate{Address: a, Of: kept, Similarity: s}
+			}
+		}
+
+		if dup != nil {
+			mergeAddress(dup.Of, a)
+			duplicates = append(duplicates, dup)
+			continue
+		}
+
+		byLocation[key] = append(byLocation[key], a)
+		unique = append(unique, a)
+	}
+	return unique, duplicates
+}
+
+// addressSimilarity returns the lower similarity of the names and the streets of two addresses with the same country
+// and postal code. Addresses with different house numbers (e.g. "Hauptstraße 1" and "Hauptstraße 11") are never
+// similar.
+func addressSimilarity(a, b *Address) float64 {
+	if houseNumber(a) != houseNumber(b) {
+		return 0
+	}
+
+	name := func(a *Address) string {
+		return foldName(a.Company + " " + a.FirstName + " " + a.LastName)
+	}
+	street := func(a *Address) string {
+		return streetAbbreviationRegexp.ReplaceAllString(foldName(a.Street+" "+a.StreetNumber), "strasse")
+	}
+
+	n, s := stringSimilarity(name(a), name(b)), stringSimilarity(street(a), street(b))
+	if n < s {
+		return n
+	}
+	return s
+}
+
+// houseNumber returns the folded house number of the address. It is split from the street if the address has no
+// StreetNumber.
+func houseNumber(a *Address) string {
+	number := a.StreetNumber
+	if number == "" {
+		_, number, _ = SplitStreet(a.Street, a.Country)
+	}
+	return foldName(strings.ReplaceAll(number, " ", ""))
+}
+
+// stringSimilarity returns 1 minus the edit distance relative to the longer string
+func stringSimilarity(a, b string) float64 {
+	maxLen := len([]rune(a))
+	if l := len([]rune(b)); l > maxLen {
+		maxLen = l
+	}
+
+	if maxLen == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(maxLen)
+}
+
+// mergeAddress fills the empty fields of dst from src
+func mergeAddress(dst, src *Address) {
+	srcFields := (&addressNormalizer{a: src}).fields()
+	for i, f := range (&addressNormalizer{a: dst}).fields() {
+		if *f.ptr == "" {
+			*f.ptr = *srcFields[i].ptr
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package shippinglabel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseVCards(t *testing.T) {
+	data := "BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"N:Mustermann;Max;;;\r\n" +
+		"FN:Max Mustermann\r\n" +
+		"ORG:Muster GmbH;Vertrieb\r\n" +
+		"TEL;TYPE=home:030 1234567\r\n" +
+		"TEL;TYPE=work;PREF=1:tel:+49 30 7654321\r\n" +
+		"item1.EMAIL:max@example.com\r\n" +
+		"ADR;TYPE=work:;3. OG;Hauptstraße 1\\nHinterhaus;Berlin;;10115;Germany\r\n" +
+		"ADR;TYPE=home:Postfach 12;;Beispielweg 5\\, Haus B;Hamb\r\n" +
+		" urg;;20095;DE\r\n" +
+		"END:VCARD\r\n" +
+		"BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:Erika Musterfrau\r\n" +
+		"END:VCARD\r\n"
+
+	addresses, err := ParseVCards(strings.NewReader(data))
+	isNoError(t, err)
+	isEqual(t, len(addresses), 2)
+
+	a := addresses[0]
+	isEqual(t, a.FirstName, "Max")
+	isEqual(t, a.LastName, "Mustermann")
+	isEqual(t, a.Company, "Muster GmbH")
+	isEqual(t, a.Street, "Hauptstraße 1")
+	isEqual(t, a.AddressAddition, "Hinterhaus, 3. OG")
+	isEqual(t, a.PostalCode, "10115")
+	isEqual(t, a.City, "Berlin")
+	isEqual(t, a.Country, "Germany")
+	isEqual(t, a.Phone, "+49 30 7654321")
+	isEqual(t, a.Mail, "max@example.com")
+
+	a = addresses[1]
+	isEqual(t, a.Street, "Beispielweg 5, Haus B")
+	isEqual(t, a.AddressAddition, "Postfach 12")
+	isEqual(t, a.City, "Hamburg")
+
+	if _, err = ParseVCards(strings.NewReader("BEGIN:VCARD\nFN:Max\n")); !errors.Is(err, ErrInvalidVCard) {
+		t.Fatalf("expected invalid vcard, got %v", err)
+	}
+}
+
+func TestParseVCards_QuotedPrintable(t *testing.T) {
+	data := "BEGIN:VCARD\n" +
+		"VERSION:2.1\n" +
+		"N:M=C3=BCller;J=C3=BCrgen\n" +
+		"ADR;HOME;ENCODING=QUOTED-PRINTABLE;CHARSET=UTF-8:;;Gartenstra=C3=9Fe 3;M=C3=BC=\n" +
+		"nchen;;80331;DE\n" +
+		"END:VCARD\n"
+
+	addresses, err := ParseVCards(strings.NewReader(data))
+	isNoError(t, err)
+	isEqual(t, len(addresses), 1)
+	isEqual(t, addresses[0].Street, "Gartenstraße 3")
+	isEqual(t, addresses[0].City, "München")
+
+	// vCard 2.1 exports use the charset of the system
+	data = "BEGIN:VCARD\n" +
+		"VERSION:2.1\n" +
+		"N;ENCODING=QUOTED-PRINTABLE;CHARSET=ISO-8859-1:M=FCller;J=FCrgen\n" +
+		"ADR;HOME;ENCODING=QUOTED-PRINTABLE;CHARSET=ISO-8859-1:;;Gartenstra=DFe 3;M=FCnchen;;80331;DE\n" +
+		"END:VCARD\n"
+
+	addresses, err = ParseVCards(strings.NewReader(data))
+	isNoError(t, err)
+	isEqual(t, len(addresses), 1)
+	isEqual(t, addresses[0].LastName, "Müller")
+	isEqual(t, addresses[0].Street, "Gartenstraße 3")
+	isEqual(t, addresses[0].City, "München")
+	isEqual(t, decodeQuotedPrintable("M=FCnchen", ""), "München")
+}
+
+func TestDecodeAddressCSV(t *testing.T) {
+	tests := []struct {
+		layout string
+		data   string
+	}{
+		{"Outlook", "First Name,Last Name,Company,Business Street,Business City,Business Postal Code,Business Country/Region,Home Street,Home City,Home Postal Code\n" +
+			"Max,Mustermann,Muster GmbH,,,,,Hauptstraße 1,Berlin,10115\n"},
+		{"Outlook (DE)", "Vorname;Nachname;Firma;Straße geschäftlich;Ort geschäftlich;Postleitzahl geschäftlich;Land/Region geschäftlich\n" +
+			"Max;Mustermann;Muster GmbH;Hauptstraße 1;Berlin;10115;Deutschland\n"},
+		{"Google Contacts", "Given Name,Family Name,Organization 1 - Name,Address 1 - Street,Address 1 - City,Address 1 - Postal Code\n" +
+			"Max,Mustermann,Muster GmbH,Hauptstraße 1,Berlin,10115\n"},
+		{"Shopify", "First Name,Last Name,Company,Address1,Address2,City,Zip,Country Code,Email\n" +
+			"Max,Mustermann,Muster GmbH,Hauptstraße 1,,Berlin,10115,DE,max@example.com\n"},
+		{"WooCommerce", "billing_first_name,billing_last_name,billing_company,billing_address_1,billing_city,billing_postcode,shipping_first_name,shipping_address_1,shipping_city,shipping_postcode\n" +
+			"Erika,Musterfrau,,Beispielweg 5,Hamburg,20095,Max,Hauptstraße 1,Berlin,10115\n"},
+	}
+
+	for _, tt := range tests {
+		r := strings.NewReader(tt.data)
+		header := strings.FieldsFunc(strings.SplitN(tt.data, "\n", 2)[0], func(r rune) bool { return r == ',' || r == ';' })
+		layout, ok := DetectAddressCSVLayout(header)
+		if !ok || layout.Name != tt.layout {
+			t.Errorf("expected layout %s, got %+v", tt.layout, layout)
+			continue
+		}
+
+		addresses, err := DecodeAddressCSV(r, nil)
+		isNoError(t, err)
+		isEqual(t, len(addresses), 1)
+		isEqual(t, addresses[0].FirstName, "Max")
+		isEqual(t, addresses[0].Street, "Hauptstraße 1")
+		isEqual(t, addresses[0].City, "Berlin")
+		isEqual(t, addresses[0].PostalCode, "10115")
+	}
+
+	if _, err := DecodeAddressCSV(strings.NewReader("a,b,c\n1,2,3\n"), nil); !errors.Is(err, ErrUnknownAddressLayout) {
+		t.Fatalf("expected unknown layout, got %v", err)
+	}
+}
+
+func TestDedupeAddresses(t *testing.T) {
+	addresses := []*Address{
+		{FirstName: "Max", LastName: "Mustermann", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "10115"},
+		{FirstName: "Max", LastName: "Mustermann", Street: "Hauptstr.", StreetNumber: "1", PostalCode: "10115", Phone: "+49301234567"},
+		{FirstName: "Max", LastName: "Mustermann", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "20095"},
+		{FirstName: "Erika", LastName: "Musterfrau", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "10115"},
+	}
+
+	unique, duplicates := DedupeAddresses(addresses, 0.85)
+	isEqual(t, len(unique), 3)
+	isEqual(t, len(duplicates), 1)
+	isEqual(t, duplicates[0].Address, addresses[1])
+	isEqual(t, duplicates[0].Of, addresses[0])
+	isEqual(t, addresses[0].Phone, "+49301234567")
+
+	// The same postal code in another country is no duplicate
+	addresses = []*Address{
+		{LastName: "Müller", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "1010", Country: "AT"},
+		{LastName: "Müller", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "1010", Country: "CH", Phone: "+41441234567"},
+		{LastName: "Müller", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "1010", Country: "Österreich"},
+	}
+	unique, duplicates = DedupeAddresses(addresses, 0.85)
+	isEqual(t, len(unique), 2)
+	isEqual(t, len(duplicates), 1)
+	isEqual(t, duplicates[0].Of, addresses[0])
+	isEqual(t, addresses[0].Phone, "")
+
+	// Different house numbers are no duplicates, also if they are part of the street
+	addresses = []*Address{
+		{LastName: "Mustermann", Street: "Hauptstraße 1", PostalCode: "10115", Country: "DE"},
+		{LastName: "Mustermann", Street: "Hauptstraße 11", PostalCode: "10115", Country: "DE"},
+		{LastName: "Mustermann", Street: "Hauptstraße", StreetNumber: "1 a", PostalCode: "10115", Country: "DE"},
+		{LastName: "Mustermann", Street: "Hauptstr.", StreetNumber: "1", PostalCode: "10115", Country: "DE"},
+	}
+	unique, duplicates = DedupeAddresses(addresses, 0.85)
+	isEqual(t, len(unique), 3)
+	isEqual(t, len(duplicates), 1)
+	isEqual(t, duplicates[0].Address, addresses[3])
+}
+
+func TestImportAddressCSV(t *testing.T) {
+	data := "First Name,Last Name,Company,Address1,Address2,City,Zip,Country Code\n" +
+		"Max,Mustermann,, Hauptstraße 1 ,,Berlin,10115,DEU\n" +
+		"Max,Mustermann,,Hauptstr. 1,,Berlin,10115,DE\n"
+
+	res, err := ImportAddressCSV(strings.NewReader(data), &AddressImportOptions{AddressType: AddressTypeShipping})
+	isNoError(t, err)
+	isEqual(t, len(res.Addresses), 1)
+	isEqual(t, len(res.Duplicates), 1)
+
+	a := res.Addresses[0]
+	isEqual(t, a.Street, "Hauptstraße")
+	isEqual(t, a.StreetNumber, "1")
+	isEqual(t, a.Country, "DE")
+	isEqual(t, a.AddressType, AddressTypeShipping)
+}
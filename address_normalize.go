@@ -0,0 +1,248 @@
+package shippinglabel
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AddressChange is a change which was made by NormalizeAddress
+type AddressChange struct {
+	Field  string
+	Old    string
+	New    string
+	Reason string
+}
+
+var (
+	whitespaceRegexp = regexp.MustCompile(`\s+`)
+
+	// careOfRegexp matches "c/o Name" and "z. Hd. Name" until the end of the value
+	careOfRegexp = regexp.MustCompile(`(?i)(?:^|[\s,]+)((?:c/o|c\.o\.|z\.\s?hd\.?|zu händen|attn\.?:?)\s+.+)$`)
+
+	// floorRegexp matches floor, apartment and building information. Keywords such as "Tür" or "Top" are also part of
+	// street names, therefore it is only applied after the house number.
+	floorRegexp = regexp.MustCompile(`(?i)(?:^|[\s,]+)(` + floorPattern + `)`)
+
+	// floorPartRegexp matches a comma separated part which contains only floor information (e.g. "Flat 3")
+	floorPartRegexp = regexp.MustCompile(`(?i)^(?:` + floorPattern + `)$`)
+
+	// numberLastRegexp splits "Hauptstraße 12a", "Kerkstraat 12-A" or "Hauptstraße 12/3/4"
+	numberLastRegexp = regexp.MustCompile(`^(.*?[^\d\s,])[\s,]+(\d+(?:\s?[a-zA-Z](?:\b|$))?(?:\s?[-/]\s?\d*\s?[a-zA-Z]?)*(?:\s?(?:bis|ter|hs|huis))?)$`)
+
+	// numberFirstRegexp splits "12 rue de la Paix", "12bis rue de la Paix" or "221B Baker Street"
+	numberFirstRegexp = regexp.MustCompile(`(?i)^(\d+(?:\s?(?:bis|ter|quater)\b|[a-z]\b)?(?:-\d+)?)[\s,]+(\D.*)$`)
+)
+
+const floorPattern = `(?:\d+\.?\s?(?:og|obergeschoss|stock|etage|floor|fl\.)|eg|erdgeschoss|dg|dachgeschoss|ug|hinterhaus|vorderhaus|seitenflügel|(?:whg\.?|wohnung|apt\.?|apartment|flat|suite|app\.?|appartement|étage|etg\.?|bus|boîte|stiege|tür|top)\s*\d[\w/-]*)\b\.?`
+
+// numberFirstCountries write the house number before the street
+var numberFirstCountries = map[string]bool{
+	"FR": true, "GB": true, "IE": true, "US": true, "CA": true, "AU": true, "NZ": true, "LU": true, "MC": true,
+}
+
+// postalCodeSpaceCountries separate the postal code in two parts (position of the space from the end)
+var postalCodeSpaceCountries = map[string]int{
+	"GB": 3, "IE": 4, "CA": 3, "NL": 2, "MT": 4,
+}
+
+// NormalizeAddress trims and collapses whitespace, splits the street and the house number, moves "c/o" and floor
+// information into the AddressAddition and formats the postal code. The address is modified in place and all changes
+// are reported.
+func NormalizeAddress(a *Address) []AddressChange {
+	n := &addressNormalizer{a: a}
+	n.trim()
+	n.careOf()
+	n.floor()
+	n.splitStreet()
+	n.postalCode()
+	return n.changes
+}
+
+type addressNormalizer struct {
+	a       *Address
+	changes []AddressChange
+}
+
+func (n *addressNormalizer) set(field string, ptr *string, value string, reason string) {
+	if *ptr == value {
+		return
+	}
+	n.changes = append(n.changes, AddressChange{Field: field, Old: *ptr, New: value, Reason: reason})
+	*ptr = value
+}
+
+// addAddition appends a value to the AddressAddition
+func (n *addressNormalizer) addAddition(value string, reason string) {
+	value = strings.Trim(value, " ,")
+	if value == "" || strings.Contains(strings.ToLower(n.a.AddressAddition), strings.ToLower(value)) {
+		return
+	}
+
+	addition := value
+	if n.a.AddressAddition != "" {
+		addition = n.a.AddressAddition + ", " + value
+	}
+	n.set("AddressAddition", &n.a.AddressAddition, addition, reason)
+}
+
+func (n *addressNormalizer) fields() []struct {
+	name string
+	ptr  *string
+} {
+	a := n.a
+	return []struct {
+		name string
+		ptr  *string
+	}{
+		{"Company", &a.Company}, {"FirstName", &a.FirstName}, {"LastName", &a.LastName}, {"Street", &a.Street},
+		{"StreetNumber", &a.StreetNumber}, {"PostalCode", &a.PostalCode}, {"AddressAddition", &a.AddressAddition},
+		{"City", &a.City}, {"Country", &a.Country}, {"State", &a.State}, {"Mail", &a.Mail}, {"Phone", &a.Phone},
+		{"VATNumber", &a.VATNumber},
+	}
+}
+
+func (n *addressNormalizer) trim() {
+	for _, f := range n.fields() {
+		n.set(f.name, f.ptr, whitespaceRegexp.ReplaceAllString(strings.TrimSpace(*f.ptr), " "), "whitespace")
+	}
+
+	if code, err := NormalizeCountry(n.a.Country); err == nil {
+		n.set("Country", &n.a.Country, code, "ISO 3166-1 alpha-2 country code")
+	}
+}
+
+// careOf moves "c/o" from the street and the name lines into the AddressAddition
+func (n *addressNormalizer) careOf() {
+	for _, f := range []struct {
+		name string
+		ptr  *string
+	}{{"Street", &n.a.Street}, {"Company", &n.a.Company}, {"FirstName", &n.a.FirstName}, {"LastName", &n.a.LastName}} {
+		m := careOfRegexp.FindStringSubmatchIndex(*f.ptr)
+		if m == nil {
+			continue
+		}
+
+		rest := strings.Trim((*f.ptr)[:m[0]], " ,")
+		if rest == "" && f.name != "Street" {
+			// The line contains only the c/o information and is used as name
+			continue
+		}
+
+		n.addAddition((*f.ptr)[m[2]:m[3]], "c/o moved to address addition")
+		n.set(f.name, f.ptr, rest, "c/o moved to address addition")
+	}
+}
+
+// floor moves floor and apartment information into the AddressAddition: comma separated parts which contain only
+// floor information (e.g. "Flat 3, 12 High Street") and the text after the house number (e.g. "Dorfstraße 3 EG
+// links"). The street name itself is never changed (e.g. "Zur Tür 3").
+func (n *addressNormalizer) floor() {
+	a := n.a
+	const reason = "floor moved to address addition"
+
+	if parts := strings.Split(a.Street, ","); len(parts) > 1 {
+		rest, floors := make([]string, 0, len(parts)), make([]string, 0)
+		for _, p := range parts {
+			switch p = strings.TrimSpace(p); {
+			case floorPartRegexp.MatchString(p):
+				floors = append(floors, p)
+			case p != "":
+				rest = append(rest, p)
+			}
+		}
+
+		if len(rest) > 0 && len(floors) > 0 {
+			for _, f := range floors {
+				n.addAddition(f, reason)
+			}
+			n.set("Street", &a.Street, strings.Join(rest, ", "), reason)
+		}
+	}
+
+	if a.StreetNumber == "" {
+		hasNumber := func(street string) bool {
+			_, _, ok := SplitStreet(street, a.Country)
+			return ok
+		}
+
+		if street, tail, ok := splitFloorTail(a.Street, hasNumber); ok {
+			n.addAddition(tail, reason)
+			n.set("Street", &a.Street, street, reason)
+		}
+	}
+
+	isNumber := func(number string) bool {
+		return number[0] >= '0' && number[0] <= '9'
+	}
+
+	if number, tail, ok := splitFloorTail(a.StreetNumber, isNumber); ok {
+		n.addAddition(tail, reason)
+		n.set("StreetNumber", &a.StreetNumber, number, reason)
+	}
+}
+
+// splitFloorTail splits the line before the first floor information whose preceding text is accepted by head (e.g.
+// "Dorfstraße 3" of "Dorfstraße 3 EG links")
+func splitFloorTail(line string, head func(s string) bool) (string, string, bool) {
+	for _, m := range floorRegexp.FindAllStringSubmatchIndex(line, -1) {
+		prefix := strings.Trim(line[:m[0]], " ,")
+		if prefix != "" && head(prefix) {
+			return prefix, strings.Trim(line[m[2]:], " ,"), true
+		}
+	}
+	return "", "", false
+}
+
+// splitStreet splits the house number from the street
+func (n *addressNormalizer) splitStreet() {
+	a := n.a
+	if a.StreetNumber != "" || a.Street == "" {
+		return
+	}
+
+	street, number, ok := SplitStreet(a.Street, a.Country)
+	if !ok {
+		return
+	}
+
+	n.set("Street", &a.Street, street, "house number split from street")
+	n.set("StreetNumber", &a.StreetNumber, number, "house number split from street")
+}
+
+// SplitStreet splits a street line into the street and the house number. The order of the country (e.g. "12 rue de la
+// Paix" in FR or "Hauptstraße 12a" in DE) is tried first.
+func SplitStreet(line string, country string) (street string, number string, ok bool) {
+	line = strings.Trim(whitespaceRegexp.ReplaceAllString(line, " "), " ,")
+	first := numberFirstCountries[strings.ToUpper(country)]
+
+	for _, numberFirst := range []bool{first, !first} {
+		if numberFirst {
+			if m := numberFirstRegexp.FindStringSubmatch(line); m != nil {
+				return strings.Trim(m[2], " ,"), strings.ReplaceAll(m[1], " ", ""), true
+			}
+			continue
+		}
+
+		if m := numberLastRegexp.FindStringSubmatch(line); m != nil {
+			return strings.Trim(m[1], " ,"), strings.ReplaceAll(m[2], " ", ""), true
+		}
+	}
+	return line, "", false
+}
+
+// postalCode uppercases the postal code and inserts the space of the country format
+func (n *addressNormalizer) postalCode() {
+	a := n.a
+	if a.PostalCode == "" {
+		return
+	}
+
+	pc := strings.ToUpper(a.PostalCode)
+	if pos, ok := postalCodeSpaceCountries[a.Country]; ok {
+		compact := strings.ReplaceAll(pc, " ", "")
+		if len(compact) > pos {
+			pc = compact[:len(compact)-pos] + " " + compact[len(compact)-pos:]
+		}
+	}
+	n.set("PostalCode", &a.PostalCode, pc, "postal code format")
+}
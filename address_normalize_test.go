@@ -0,0 +1,98 @@
+package shippinglabel
+
+import "testing"
+
+func TestSplitStreet(t *testing.T) {
+	tests := []struct {
+		line, country, street, number string
+	}{
+		{"Hauptstraße 12a", "DE", "Hauptstraße", "12a"},
+		{"Hauptstraße 12 a", "DE", "Hauptstraße", "12a"},
+		{"Straße des 17. Juni 135", "DE", "Straße des 17. Juni", "135"},
+		{"Am Markt 1-3", "DE", "Am Markt", "1-3"},
+		{"Mariahilfer Straße 12/3/4", "AT", "Mariahilfer Straße", "12/3/4"},
+		{"Kerkstraat 12-A", "NL", "Kerkstraat", "12-A"},
+		{"12 rue de la Paix", "FR", "rue de la Paix", "12"},
+		{"12bis rue de la Paix", "FR", "rue de la Paix", "12bis"},
+		{"221B Baker Street", "GB", "Baker Street", "221B"},
+		{"Baker Street 221B", "GB", "Baker Street", "221B"},
+	}
+
+	for _, tt := range tests {
+		street, number, ok := SplitStreet(tt.line, tt.country)
+		if !ok || street != tt.street || number != tt.number {
+			t.Errorf("SplitStreet(%q, %s) = %q, %q, %v", tt.line, tt.country, street, number, ok)
+		}
+	}
+
+	if _, _, ok := SplitStreet("Hauptstraße", "DE"); ok {
+		t.Errorf("expected no house number")
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	a := &Address{
+		FirstName:  "  Max   Mustermann ",
+		Street:     "Hauptstraße 12a, 3. OG c/o Schmidt",
+		PostalCode: "10115",
+		City:       "Berlin",
+		Country:    "de",
+	}
+
+	changes := NormalizeAddress(a)
+	isEqual(t, a.FirstName, "Max Mustermann")
+	isEqual(t, a.Street, "Hauptstraße")
+	isEqual(t, a.StreetNumber, "12a")
+	isEqual(t, a.AddressAddition, "c/o Schmidt, 3. OG")
+	isEqual(t, a.Country, "DE")
+
+	fields := make([]string, 0, len(changes))
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	for _, f := range []string{"FirstName", "Street", "StreetNumber", "AddressAddition", "Country"} {
+		if !contains(fields, f) {
+			t.Errorf("expected change of %s, got %v", f, changes)
+		}
+	}
+
+	a = &Address{Street: "Flat 3, 12 High Street", PostalCode: "sw1a1aa", Country: "GB"}
+	NormalizeAddress(a)
+	isEqual(t, a.Street, "High Street")
+	isEqual(t, a.StreetNumber, "12")
+	isEqual(t, a.AddressAddition, "Flat 3")
+	isEqual(t, a.PostalCode, "SW1A 1AA")
+
+	a = &Address{Street: "Kerkstraat", StreetNumber: "12", PostalCode: "1234ab", Country: "NL"}
+	NormalizeAddress(a)
+	isEqual(t, a.PostalCode, "1234 AB")
+
+	// A normalized address is not changed again
+	isEqual(t, len(NormalizeAddress(a)), 0)
+
+	a = &Address{Company: "Müller & Co. KG", Street: "Türkenstraße 5", Country: "DE"}
+	NormalizeAddress(a)
+	isEqual(t, a.Company, "Müller & Co. KG")
+	isEqual(t, a.Street, "Türkenstraße")
+	isEqual(t, a.AddressAddition, "")
+
+	// Floor keywords in street names are kept, floor information after the house number is moved
+	tests := []struct {
+		street, number, country      string
+		expStreet, expNumber, expAdd string
+	}{
+		{"Zur Tür 3", "", "DE", "Zur Tür", "3", ""},
+		{"Im Top 2", "", "AT", "Im Top", "2", ""},
+		{"Rue de la Boîte 5", "", "BE", "Rue de la Boîte", "5", ""},
+		{"Dorfstraße 3 EG links", "", "DE", "Dorfstraße", "3", "EG links"},
+		{"Mariahilfer Straße 12 Top 4", "", "AT", "Mariahilfer Straße", "12", "Top 4"},
+		{"Zur Tür", "7, Tür 2", "DE", "Zur Tür", "7", "Tür 2"},
+	}
+	for _, tt := range tests {
+		a = &Address{Street: tt.street, StreetNumber: tt.number, Country: tt.country}
+		NormalizeAddress(a)
+		if a.Street != tt.expStreet || a.StreetNumber != tt.expNumber || a.AddressAddition != tt.expAdd {
+			t.Errorf("%q %q: got %q %q %q", tt.street, tt.number, a.Street, a.StreetNumber, a.AddressAddition)
+		}
+	}
+}
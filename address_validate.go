@@ -0,0 +1,182 @@
+package shippinglabel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AddressFormat describes the postal code and the state of a country
+type AddressFormat struct {
+	PostalCode         *regexp.Regexp // Format of the uppercase postal code. nil if the format is not checked
+	PostalCodeOptional bool           // The postal code may be empty (e.g. IE)
+	NoPostalCode       bool           // The country has no postal codes (e.g. HK)
+	StateRequired      bool
+	States             []string // Valid state codes. Empty if the state is not checked
+}
+
+// AddressValidator checks addresses offline against the AddressFormat of their country
+type AddressValidator struct {
+	Formats map[string]*AddressFormat // Key: ISO 3166-1 alpha-2 code
+}
+
+// AddressError contains all problems of an address
+type AddressError struct {
+	Problems []string
+}
+
+func (m *AddressError) Error() string {
+	return "invalid address: " + strings.Join(m.Problems, "; ")
+}
+
+var (
+	usStates = strings.Fields(`AL AK AZ AR CA CO CT DE DC FL GA HI ID IL IN IA KS KY LA ME MD MA MI MN MS MO MT NE NV NH NJ NM
+NY NC ND OH OK OR PA RI SC SD TN TX UT VT VA WA WV WI WY AS GU MP PR VI UM AA AE AP`)
+	caStates = strings.Fields(`AB BC MB NB NL NS NT NU ON PE QC SK YT`)
+	auStates = strings.Fields(`ACT NSW NT QLD SA TAS VIC WA`)
+)
+
+func postalCodeFormat(pattern string) *AddressFormat {
+	return &AddressFormat{PostalCode: regexp.MustCompile(`^(?:` + pattern + `)$`)}
+}
+
+// DefaultAddressFormats contains the address formats of the most common destination countries
+var DefaultAddressFormats = map[string]*AddressFormat{
+	"AT": postalCodeFormat(`\d{4}`),
+	"AU": {PostalCode: regexp.MustCompile(`^\d{4}$`), StateRequired: true, States: auStates},
+	"BE": postalCodeFormat(`\d{4}`),
+	"BG": postalCodeFormat(`\d{4}`),
+	"BR": postalCodeFormat(`\d{5}-?\d{3}`),
+	"CA": {PostalCode: regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] ?\d[ABCEGHJ-NPRSTV-Z]\d$`), StateRequired: true, States: caStates},
+	"CH": postalCodeFormat(`\d{4}`),
+	"CN": postalCodeFormat(`\d{6}`),
+	"CY": postalCodeFormat(`\d{4}`),
+	"CZ": postalCodeFormat(`\d{3} ?\d{2}`),
+	"DE": postalCodeFormat(`\d{5}`),
+	"DK": postalCodeFormat(`\d{4}`),
+	"EE": postalCodeFormat(`\d{5}`),
+	"ES": postalCodeFormat(`\d{5}`),
+	"FI": postalCodeFormat(`\d{5}`),
+	"FR": postalCodeFormat(`\d{5}`),
+	"GB": postalCodeFormat(`GIR ?0AA|[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}`),
+	"GR": postalCodeFormat(`\d{3} ?\d{2}`),
+	"HK": {NoPostalCode: true},
+	"HR": postalCodeFormat(`\d{5}`),
+	"HU": postalCodeFormat(`\d{4}`),
+	"IE": {PostalCode: regexp.MustCompile(`^(?:[AC-FHKNPRTV-Y]\d{2}|D6W) ?[0-9AC-FHKNPRTV-Y]{4}$`), PostalCodeOptional: true},
+	"IN": postalCodeFormat(`\d{6}`),
+	"IT": postalCodeFormat(`\d{5}`),
+	"JP": postalCodeFormat(`\d{3}-?\d{4}`),
+	"LI": postalCodeFormat(`94(?:8[5-9]|9[0-8])`),
+	"LT": postalCodeFormat(`(?:LT-)?\d{5}`),
+	"LU": postalCodeFormat(`(?:L-)?\d{4}`),
+	"LV": postalCodeFormat(`(?:LV-)?\d{4}`),
+	"MC": postalCodeFormat(`980\d{2}`),
+	"MT": postalCodeFormat(`[A-Z]{3} ?\d{4}`),
+	"NL": postalCodeFormat(`[1-9]\d{3} ?[A-Z]{2}`),
+	"NO": postalCodeFormat(`\d{4}`),
+	"PL": postalCodeFormat(`\d{2}-\d{3}`),
+	"PT": postalCodeFormat(`\d{4}-\d{3}`),
+	"RO": postalCodeFormat(`\d{6}`),
+	"RU": postalCodeFormat(`\d{6}`),
+	"SE": postalCodeFormat(`\d{3} ?\d{2}`),
+	"SI": postalCodeFormat(`\d{4}`),
+	"SK": postalCodeFormat(`\d{3} ?\d{2}`),
+	"TR": postalCodeFormat(`\d{5}`),
+	"US": {PostalCode: regexp.MustCompile(`^\d{5}(?:-\d{4})?$`), StateRequired: true, States: usStates},
+}
+
+// DefaultAddressValidator uses the DefaultAddressFormats
+var DefaultAddressValidator = &AddressValidator{Formats: DefaultAddressFormats}
+
+// Validate checks the required fields, the country code, the postal code and the state of the address. All problems
+// are returned with an *AddressError.
+func (m *AddressValidator) Validate(a *Address) error {
+	if a == nil {
+		return &AddressError{Problems: []string{"address is required"}}
+	}
+
+	problems := make([]string, 0)
+	if strings.TrimSpace(a.Company+a.FirstName+a.LastName) == "" {
+		problems = append(problems, "name is required")
+	}
+
+	if strings.TrimSpace(a.Street) == "" {
+		problems = append(problems, "street is required")
+	}
+
+	if strings.TrimSpace(a.City) == "" {
+		problems = append(problems, "city is required")
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(a.Country))
+	switch {
+	case country == "":
+		problems = append(problems, "country is required")
+	case !IsCountryCode(country):
+		problems = append(problems, fmt.Sprintf("country %q is not an ISO 3166-1 alpha-2 code", a.Country))
+	}
+
+	pc := strings.ToUpper(strings.TrimSpace(a.PostalCode))
+	state := strings.ToUpper(strings.TrimSpace(a.State))
+	f := m.Formats[country]
+	switch {
+	case f == nil || f.NoPostalCode:
+	case pc == "":
+		if !f.PostalCodeOptional {
+			problems = append(problems, "postal code is required")
+		}
+	case f.PostalCode != nil && !f.PostalCode.MatchString(pc):
+		problems = append(problems, fmt.Sprintf("postal code %q is invalid for %s", a.PostalCode, country))
+	}
+
+	if f != nil {
+		switch {
+		case state == "" && f.StateRequired:
+			problems = append(problems, fmt.Sprintf("state is required for %s", country))
+		case state != "" && len(f.States) > 0 && !contains(f.States, state):
+			problems = append(problems, fmt.Sprintf("state %q is invalid for %s", a.State, country))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &AddressError{Problems: problems}
+	}
+	return nil
+}
+
+// CheckShipment validates the receiver and, if set, the sender of the shipment. It can be used as ShipmentCheck.
+func (m *AddressValidator) CheckShipment(s *Shipment) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	problems := make([]string, 0)
+	for _, a := range []struct {
+		name    string
+		address *Address
+	}{{"sender", s.Sender}, {"receiver", s.Receiver}} {
+		// The default sender address of the user is used if the shipment has none
+		if a.name == "sender" && a.address == nil {
+			continue
+		}
+
+		var addrErr *AddressError
+		if err := m.Validate(a.address); errors.As(err, &addrErr) {
+			for _, p := range addrErr.Problems {
+				problems = append(problems, a.name+": "+p)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &AddressError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateAddress validates an address with the DefaultAddressValidator
+func ValidateAddress(a *Address) error {
+	return DefaultAddressValidator.Validate(a)
+}
@@ -0,0 +1,65 @@
+package shippinglabel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	valid := []*Address{
+		{LastName: "Mustermann", Street: "Hauptstraße", City: "Berlin", PostalCode: "10115", Country: "DE"},
+		{LastName: "Huber", Street: "Ring", City: "Wien", PostalCode: "1010", Country: "AT"},
+		{LastName: "de Vries", Street: "Kerkstraat", City: "Amsterdam", PostalCode: "1017 GC", Country: "NL"},
+		{LastName: "Holmes", Street: "Baker Street", City: "London", PostalCode: "NW1 6XE", Country: "GB"},
+		{LastName: "Smith", Street: "Main Street", City: "Austin", PostalCode: "73301", State: "TX", Country: "US"},
+		{LastName: "Murphy", Street: "Main Street", City: "Cork", Country: "IE"},
+		{LastName: "Mustermann", Street: "Hauptstraße", City: "Berlin", PostalCode: "10115", Country: " de"},
+	}
+	for _, a := range valid {
+		isNoError(t, ValidateAddress(a))
+	}
+
+	tests := []struct {
+		address  *Address
+		problems int
+	}{
+		{&Address{LastName: "Mustermann", Street: "Hauptstraße", City: "Berlin", PostalCode: "1011", Country: "DE"}, 1},
+		{&Address{LastName: "Huber", Street: "Ring", City: "Wien", PostalCode: "10100", Country: "AT"}, 1},
+		{&Address{LastName: "de Vries", Street: "Kerkstraat", City: "Amsterdam", PostalCode: "1017", Country: "NL"}, 1},
+		{&Address{LastName: "Holmes", Street: "Baker Street", City: "London", PostalCode: "NW1", Country: "GB"}, 1},
+		{&Address{LastName: "Smith", Street: "Main Street", City: "Austin", PostalCode: "73301", Country: "US"}, 1},
+		{&Address{LastName: "Smith", Street: "Main Street", City: "Perth", PostalCode: "6000", State: "XX", Country: "AU"}, 1},
+		{&Address{LastName: "Mustermann", Street: "Hauptstraße", City: "Berlin", PostalCode: "10115", Country: "DEU"}, 1},
+		{&Address{LastName: "Mustermann", Street: "Hauptstraße", City: "Berlin", PostalCode: "1011", Country: "de"}, 1},
+		{&Address{Country: "DE"}, 4},
+	}
+	for _, tt := range tests {
+		var addrErr *AddressError
+		if err := ValidateAddress(tt.address); !errors.As(err, &addrErr) || len(addrErr.Problems) != tt.problems {
+			t.Errorf("%+v: expected %d problems, got %v", tt.address, tt.problems, err)
+		}
+	}
+}
+
+func TestAPIContext_ValidateShipmentWith(t *testing.T) {
+	calls := 0
+	c := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	s := &Shipment{Receiver: &Address{LastName: "Mustermann", Street: "Hauptstraße", City: "Berlin", PostalCode: "1011", Country: "DE"}}
+	err := c.ValidateShipmentWith(context.Background(), s, DefaultAddressValidator.CheckShipment, CheckServices)
+
+	var addrErr *AddressError
+	if !errors.As(err, &addrErr) || addrErr.Problems[0] != `receiver: postal code "1011" is invalid for DE` {
+		t.Fatalf("expected address error, got %v", err)
+	}
+	isEqual(t, calls, 0)
+
+	s.Receiver.PostalCode = "10115"
+	isNoError(t, c.ValidateShipmentWith(context.Background(), s, DefaultAddressValidator.CheckShipment, CheckServices))
+	isEqual(t, calls, 1)
+}
@@ -3,6 +3,7 @@ package shippinglabel
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
@@ -71,6 +72,25 @@ func (c *APIContext) Metadata(ctx context.Context) (resp []*CarrierMetadata, err
 	return resp, c.send(ctx, req)
 }
 
+// MetadataIfNoneMatch returns the carrier metadata and its ETag. ErrNotModified is returned if the metadata still
+// matches the etag.
+// [GET]: /metadata/carriers
+func (c *APIContext) MetadataIfNoneMatch(ctx context.Context, etag string) (resp []*CarrierMetadata, respETag string, err error) {
+	req := c.request().SetMethod(http.MethodGet).SetAccept(HeaderContentTypeJSON).SetPath("/metadata/carriers")
+	if etag != "" {
+		req.SetHeader("If-None-Match", etag)
+	}
+	req.SetResponseHandler(func(res *http.Response) error {
+		respETag = res.Header.Get("ETag")
+		if res.StatusCode == http.StatusNotModified {
+			return ErrNotModified
+		}
+		return json.NewDecoder(res.Body).Decode(&resp)
+	})
+	err = c.send(ctx, req)
+	return resp, respETag, err
+}
+
 // ADDRESSES
 
 // ListAddresses returns all available user addresses
@@ -198,25 +218,50 @@ func (c *APIContext) DeleteCarrier(ctx context.Context, code CarrierCode) (err e
 
 // CARRIER PRODUCTS
 
+// ListCarrierProducts returns all contract products of a carrier
+// [GET]: /carriers/{code}/products
+func (c *APIContext) ListCarrierProducts(ctx context.Context, code CarrierCode) (resp []*Product, err error) {
+	req := c.request().SetMethod(http.MethodGet).ToJSON(&resp).SetPathf("/carriers/%s/products", code)
+	return resp, c.send(ctx, req)
+}
+
+// CreateCarrierProduct creates a contract product of a carrier
+// [POST]: /carriers/{code}/products
+func (c *APIContext) CreateCarrierProduct(ctx context.Context, code CarrierCode, v *Product) (resp *Product, err error) {
+	req := c.request().SetMethod(http.MethodPost).SetJSON(v).ToJSON(&resp).SetPathf("/carriers/%s/products", code)
+	return resp, c.send(ctx, req)
+}
+
+// UpdateCarrierProduct updates a contract product of a carrier
+// [PUT]: /carriers/{code}/products/{id}
+func (c *APIContext) UpdateCarrierProduct(ctx context.Context, code CarrierCode, v *Product) (err error) {
+	req := c.request().SetMethod(http.MethodPut).SetJSON(v).SetPathf("/carriers/%s/products/%d", code, v.ID)
+	return c.send(ctx, req)
+}
+
+// DeleteCarrierProduct deletes a contract product of a carrier
+// [DELETE]: /carriers/{code}/products/{id}
+func (c *APIContext) DeleteCarrierProduct(ctx context.Context, code CarrierCode, id int) (err error) {
+	req := c.request().SetMethod(http.MethodDelete).SetPathf("/carriers/%s/products/%d", code, id)
+	return c.send(ctx, req)
+}
+
 // CreateDHLProduct creates a DHL product
 // [POST]: /carriers/DHL/products
 func (c *APIContext) CreateDHLProduct(ctx context.Context, v *Product) (resp *Product, err error) {
-	req := c.request().SetMethod(http.MethodPost).SetJSON(v).ToJSON(&resp).SetPath("/carriers/DHL/products")
-	return resp, c.send(ctx, req)
+	return c.CreateCarrierProduct(ctx, CarrierDHL, v)
 }
 
 // UpdateDHLProduct updates a DHL product
 // [PUT]: /carriers/DHL/products/{id}
 func (c *APIContext) UpdateDHLProduct(ctx context.Context, v *Product) (err error) {
-	req := c.request().SetMethod(http.MethodPut).SetJSON(v).SetPathf("/carriers/DHL/products/%d", v.ID)
-	return c.send(ctx, req)
+	return c.UpdateCarrierProduct(ctx, CarrierDHL, v)
 }
 
 // DeleteDHLProduct deletes a DHL product
 // [DELETE]: /carriers/DHL/products/{id}
 func (c *APIContext) DeleteDHLProduct(ctx context.Context, id int) (err error) {
-	req := c.request().SetMethod(http.MethodDelete).SetPathf("/carriers/DHL/products/%d", id)
-	return c.send(ctx, req)
+	return c.DeleteCarrierProduct(ctx, CarrierDHL, id)
 }
 
 // SHIPMENTS
@@ -252,6 +297,15 @@ func (c *APIContext) ValidateShipment(ctx context.Context, v *Shipment) (err err
 	return c.send(ctx, req)
 }
 
+// ValidateShipmentWith runs the offline checks and validates the shipment only if all checks passed
+// [POST]: /shipments/validate
+func (c *APIContext) ValidateShipmentWith(ctx context.Context, v *Shipment, checks ...ShipmentCheck) (err error) {
+	if err = CheckShipment(v, checks...); err != nil {
+		return err
+	}
+	return c.ValidateShipment(ctx, v)
+}
+
 // CreateShipment creates a shipment
 // [POST]: /shipments
 func (c *APIContext) CreateShipment(ctx context.Context, v *Shipment) (resp *Shipment, err error) {
@@ -362,28 +416,28 @@ func (c *APIContext) UploadCSVFile(ctx context.Context, csv []byte, csvProfileID
 
 // ListJobs returns all jobs
 // [GET]: /shipments/jobs
-func (c *APIContext) ListJobs(ctx context.Context) (resp []*ShipmentQueueItem, err error) {
+func (c *APIContext) ListJobs(ctx context.Context) (resp []*ShipmentJob, err error) {
 	req := c.request().SetMethod(http.MethodGet).ToJSON(&resp).SetPath("/shipments/jobs")
 	return resp, c.send(ctx, req)
 }
 
 // CreateJob creates a job
 // [POST]: /shipments/jobs
-func (c *APIContext) CreateJob(ctx context.Context, v *ShipmentQueueItem) (resp *ShipmentQueueItem, err error) {
+func (c *APIContext) CreateJob(ctx context.Context, v *ShipmentJob) (resp *ShipmentJob, err error) {
 	req := c.request().SetMethod(http.MethodPost).ToJSON(&resp).SetJSON(v).SetPath("/shipments/jobs")
 	return resp, c.send(ctx, req)
 }
 
 // GetJob returns a job
 // [GET]: /shipments/jobs/{id}
-func (c *APIContext) GetJob(ctx context.Context, id int) (resp *ShipmentQueueItem, err error) {
+func (c *APIContext) GetJob(ctx context.Context, id int) (resp *ShipmentJob, err error) {
 	req := c.request().SetMethod(http.MethodGet).ToJSON(&resp).SetPathf("/shipments/jobs/%d", id)
 	return resp, c.send(ctx, req)
 }
 
 // UpdateJob updates a job
 // [PUT]: /shipments/jobs/{id}
-func (c *APIContext) UpdateJob(ctx context.Context, v *ShipmentQueueItem) (err error) {
+func (c *APIContext) UpdateJob(ctx context.Context, v *ShipmentJob) (err error) {
 	req := c.request().SetMethod(http.MethodPut).SetJSON(v).SetPathf("/shipments/jobs/%d", v.ID)
 	return c.send(ctx, req)
 }
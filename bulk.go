@@ -0,0 +1,206 @@
+package shippinglabel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// DefaultBulkBatchSize is the maximum number of shipments per bulk request
+const DefaultBulkBatchSize = 100
+
+// BulkOptions configures CreateShipmentsBulk
+type BulkOptions struct {
+	BatchSize int // Maximum number of shipments per request. Default: DefaultBulkBatchSize
+	// Retry the failed shipments with single CreateShipment calls. Only shipments of batches which never reached the
+	// server (connection errors, 502 and 503 responses without a body) and shipments with a 502 or 503 status are
+	// retried. Other errors (e.g. 500 or 504) may have created the shipments and are reported as failures.
+	Fallback bool
+}
+
+// BulkItem pairs an input shipment with the created shipment or the error
+type BulkItem struct {
+	Index    int       // Index of the input shipment
+	Input    *Shipment // Input shipment
+	Shipment *Shipment // Response shipment, nil if the request failed
+	Err      error     // The Status.Error of the shipment or the request error
+
+	retry bool // The shipment was not created and can be sent again
+}
+
+// BulkResult contains a BulkItem for every input shipment in the order of the input
+type BulkResult struct {
+	Items []*BulkItem
+}
+
+// Shipments returns the created shipments
+func (m *BulkResult) Shipments() []*Shipment {
+	res := make([]*Shipment, 0, len(m.Items))
+	for _, item := range m.Items {
+		if item.Err == nil {
+			res = append(res, item.Shipment)
+		}
+	}
+	return res
+}
+
+// Failed returns the items which could not be created
+func (m *BulkResult) Failed() []*BulkItem {
+	res := make([]*BulkItem, 0)
+	for _, item := range m.Items {
+		if item.Err != nil {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// CreateShipmentsBulk creates the shipments in batches of /shipments/bulk requests and reports the result of every
+// input shipment. opts can be nil. An error is only returned if the context was cancelled.
+func (c *APIContext) CreateShipmentsBulk(ctx context.Context, v []*Shipment, opts *BulkOptions) (*BulkResult, error) {
+	o := BulkOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBulkBatchSize
+	}
+
+	res := &BulkResult{Items: make([]*BulkItem, 0, len(v))}
+	for i, s := range v {
+		res.Items = append(res.Items, &BulkItem{Index: i, Input: s})
+	}
+
+	for start := 0; start < len(res.Items); start += o.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		end := start + o.BatchSize
+		if end > len(res.Items) {
+			end = len(res.Items)
+		}
+		c.createBatch(ctx, res.Items[start:end])
+	}
+
+	if !o.Fallback {
+		return res, ctx.Err()
+	}
+
+	for _, item := range res.Failed() {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		if !item.retry {
+			continue
+		}
+
+		item.Shipment, item.Err = c.CreateShipment(ctx, item.Input)
+		if item.Err == nil {
+			item.Err = shipmentError(item.Shipment)
+		}
+	}
+	return res, ctx.Err()
+}
+
+// createBatch sends a bulk request and pairs the response with the items
+func (c *APIContext) createBatch(ctx context.Context, items []*BulkItem) {
+	batch := make([]*Shipment, 0, len(items))
+	for _, item := range items {
+		batch = append(batch, item.Input)
+	}
+
+	created, err := c.CreateShipments(ctx, batch)
+	if err != nil {
+		for _, item := range items {
+			item.Err, item.retry = err, notAccepted(err)
+		}
+		return
+	}
+
+	if len(created) != len(batch) || !sameReferences(batch, created) {
+		pairByReference(items, created)
+		return
+	}
+
+	for i, item := range items {
+		item.setShipment(created[i])
+	}
+}
+
+// setShipment sets the response shipment. Shipments with a 502 or 503 status were not created and can be retried.
+func (m *BulkItem) setShipment(s *Shipment) {
+	m.Shipment, m.Err = s, shipmentError(s)
+	m.retry = m.Err != nil && s != nil && s.ID == 0 && s.Status != nil && unavailable(s.Status.Code)
+}
+
+// pairByReference pairs a response which does not match the request by the shipment references. Items without a
+// unique reference in the request and the response are failed with ErrBulkResponseMismatch and are never retried,
+// because their shipments may have been created.
+func pairByReference(items []*BulkItem, created []*Shipment) {
+	inputs := make(map[string][]*BulkItem, len(items))
+	for _, item := range items {
+		if item.Input != nil && item.Input.Reference != "" {
+			inputs[item.Input.Reference] = append(inputs[item.Input.Reference], item)
+		}
+	}
+
+	responses := make(map[string][]*Shipment, len(created))
+	for _, s := range created {
+		if s != nil && s.Reference != "" {
+			responses[s.Reference] = append(responses[s.Reference], s)
+		}
+	}
+
+	for _, item := range items {
+		var ref string
+		if item.Input != nil {
+			ref = item.Input.Reference
+		}
+
+		if ref != "" && len(inputs[ref]) == 1 && len(responses[ref]) == 1 {
+			item.setShipment(responses[ref][0])
+			continue
+		}
+
+		item.Err = fmt.Errorf("%w: expected %d shipments, got %d", ErrBulkResponseMismatch, len(items), len(created))
+		item.retry = false
+	}
+}
+
+// sameReferences returns whether the response is in the order of the request
+func sameReferences(batch []*Shipment, created []*Shipment) bool {
+	for i, s := range created {
+		if s != nil && batch[i] != nil && s.Reference != batch[i].Reference {
+			return false
+		}
+	}
+	return true
+}
+
+// notAccepted returns whether a bulk request failed before it reached the server: the connection could not be
+// established or a proxy responded with 502 or 503 without a body
+func notAccepted(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return unavailable(statusErr.StatusCode)
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// unavailable returns whether the status code shows that the request was not processed. 500 and 504 are not included,
+// because the server may have created the shipments before it failed or timed out.
+func unavailable(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable
+}
@@ -0,0 +1,124 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAPIContext_CreateShipmentsBulk(t *testing.T) {
+	var bulkRequests, singleRequests int
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/shipments/bulk":
+			bulkRequests++
+			var batch []*Shipment
+			_ = json.NewDecoder(r.Body).Decode(&batch)
+			for i, s := range batch {
+				if s.Reference == "retry" {
+					s.Status = &Status{Code: 503, Error: &Error{Message: "carrier unavailable"}}
+					continue
+				}
+				if s.Reference == "timeout" {
+					s.Status = &Status{Code: 504, Error: &Error{Message: "carrier timeout"}}
+					continue
+				}
+				s.ID = bulkRequests*10 + i
+			}
+			_ = json.NewEncoder(w).Encode(batch)
+		case "/shipments":
+			singleRequests++
+			s := &Shipment{}
+			_ = json.NewDecoder(r.Body).Decode(s)
+			s.ID = 99
+			_ = json.NewEncoder(w).Encode(s)
+		}
+	}))
+
+	input := []*Shipment{{Reference: "a"}, {Reference: "retry"}, {Reference: "b"}, {Reference: "c"}, {Reference: "timeout"}}
+	res, err := api.CreateShipmentsBulk(context.Background(), input, &BulkOptions{BatchSize: 2})
+	isNoError(t, err)
+	isEqual(t, 3, bulkRequests)
+	isEqual(t, 3, len(res.Shipments()))
+	failed := res.Failed()
+	isEqual(t, 2, len(failed))
+	isEqual(t, 1, failed[0].Index)
+	isEqual(t, "carrier unavailable", failed[0].Err.Error())
+
+	// The timed out shipment may have been created and is not sent again
+	res, err = api.CreateShipmentsBulk(context.Background(), input, &BulkOptions{BatchSize: 2, Fallback: true})
+	isNoError(t, err)
+	failed = res.Failed()
+	isEqual(t, 1, len(failed))
+	isEqual(t, 4, failed[0].Index)
+	isEqual(t, 1, singleRequests)
+	isEqual(t, 99, res.Items[1].Shipment.ID)
+}
+
+func TestAPIContext_CreateShipmentsBulk_Fallback(t *testing.T) {
+	var mode string
+	var singleRequests int
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/shipments" {
+			singleRequests++
+			_ = json.NewEncoder(w).Encode(&Shipment{ID: 99})
+			return
+		}
+
+		var batch []*Shipment
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		switch mode {
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "timeout":
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case "invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"invalid receiver"}`))
+		case "partial":
+			// The response is missing the first shipment and is out of order
+			batch[1].ID, batch[2].ID = 2, 3
+			_ = json.NewEncoder(w).Encode([]*Shipment{batch[2], batch[1]})
+		}
+	}))
+
+	input := []*Shipment{{Reference: "a"}, {Reference: "b"}, {Reference: "c"}}
+	opts := &BulkOptions{Fallback: true}
+
+	mode = "unavailable"
+	res, err := api.CreateShipmentsBulk(context.Background(), input, opts)
+	isNoError(t, err)
+	isEqual(t, 0, len(res.Failed()))
+	isEqual(t, 3, singleRequests)
+
+	// A gateway timeout does not show whether the server created the shipments
+	mode, singleRequests = "timeout", 0
+	res, err = api.CreateShipmentsBulk(context.Background(), input, opts)
+	isNoError(t, err)
+	isEqual(t, 3, len(res.Failed()))
+	isEqual(t, 0, singleRequests)
+	var statusErr *StatusError
+	if !errors.As(res.Failed()[0].Err, &statusErr) || statusErr.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected status error, got %v", res.Failed()[0].Err)
+	}
+
+	mode, singleRequests = "invalid", 0
+	res, err = api.CreateShipmentsBulk(context.Background(), input, opts)
+	isNoError(t, err)
+	isEqual(t, 3, len(res.Failed()))
+	isEqual(t, 0, singleRequests)
+
+	mode = "partial"
+	res, err = api.CreateShipmentsBulk(context.Background(), input, opts)
+	isNoError(t, err)
+	isEqual(t, 0, singleRequests)
+	isEqual(t, 2, res.Items[1].Shipment.ID)
+	isEqual(t, 3, res.Items[2].Shipment.ID)
+	failed := res.Failed()
+	isEqual(t, 1, len(failed))
+	if !errors.Is(failed[0].Err, ErrBulkResponseMismatch) {
+		t.Fatalf("expected mismatch, got %v", failed[0].Err)
+	}
+}
@@ -56,7 +56,7 @@ type Carrier struct {
 	LabelFormat          string            `json:"labelFormat,omitempty"` // Default label format for shipments
 	Created              *time.Time        `json:"created,omitempty"`
 	Services             []*CarrierService `json:"services,omitempty"`   // Additional carrier services
-	Parameters           map[string]any    `json:"parameters,omitempty"` // Additional parameters for the carrier (e.h. DHL EKP)
+	Parameters           map[string]any    `json:"parameters,omitempty"` // Additional parameters for the carrier (e.g. DHL EKP), see CarrierParameters
 }
 
 type CarrierService struct {
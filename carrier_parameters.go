@@ -0,0 +1,267 @@
+package shippinglabel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// CarrierParameters are the typed account parameters of a carrier
+type CarrierParameters interface {
+	CarrierCode() CarrierCode
+	Validate() error
+}
+
+// SetParameters validates the typed parameters and merges them into the carrier parameters. Parameters which are not
+// part of p (e.g. server-side parameters) are kept.
+func (m *Carrier) SetParameters(p CarrierParameters) error {
+	if m.Code == "" {
+		m.Code = p.CarrierCode()
+	}
+
+	if m.Code != p.CarrierCode() {
+		return fmt.Errorf("%w: %T cannot be used for carrier %s", ErrWrongType, p, m.Code)
+	}
+
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	params, err := toParameterMap(p)
+	if err != nil {
+		return err
+	}
+
+	if m.Parameters == nil {
+		m.Parameters = make(map[string]any, len(params))
+	}
+
+	// Typed keys which are empty now are removed
+	for _, key := range parameterKeys(p) {
+		delete(m.Parameters, key)
+	}
+
+	for k, v := range params {
+		m.Parameters[k] = v
+	}
+
+	if len(m.Parameters) == 0 {
+		m.Parameters = nil
+	}
+	return nil
+}
+
+// DecodeParameters decodes and validates the carrier parameters
+func (m *Carrier) DecodeParameters(p CarrierParameters) error {
+	if m.Code != p.CarrierCode() {
+		return fmt.Errorf("%w: carrier %s cannot be decoded into %T", ErrWrongType, m.Code, p)
+	}
+
+	if err := fromParameterMap(m.Parameters, p); err != nil {
+		return err
+	}
+	return p.Validate()
+}
+
+// TypedParameters returns the typed parameters of the carrier. nil is returned for carriers without typed parameters.
+func (m *Carrier) TypedParameters() (CarrierParameters, error) {
+	factory, ok := carrierParameters[m.Code]
+	if !ok {
+		return nil, nil
+	}
+
+	p := factory()
+	if err := m.DecodeParameters(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetCarrierParameters returns the typed parameters of a carrier
+func (c *APIContext) GetCarrierParameters(ctx context.Context, code CarrierCode) (CarrierParameters, error) {
+	carrier, err := c.GetCarrier(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if carrier == nil {
+		return nil, fmt.Errorf("%w: carrier %s", ErrEmptyResponse, code)
+	}
+	return carrier.TypedParameters()
+}
+
+// carrierParameters contains the typed parameters of the carriers
+var carrierParameters = map[CarrierCode]func() CarrierParameters{
+	CarrierDHL:    func() CarrierParameters { return &DHLParameters{} },
+	CarrierDPD:    func() CarrierParameters { return &DPDParameters{} },
+	CarrierGLS:    func() CarrierParameters { return &GLSParameters{} },
+	CarrierUPS:    func() CarrierParameters { return &UPSParameters{} },
+	CarrierPostAT: func() CarrierParameters { return &PostATParameters{} },
+}
+
+var (
+	ekpRegexp           = regexp.MustCompile(`^\d{10}$`)
+	participationRegexp = regexp.MustCompile(`^[0-9A-Z]{2}$`)
+	procedureRegexp     = regexp.MustCompile(`^\d{2}$`)
+	dpdDepotRegexp      = regexp.MustCompile(`^\d{4}$`)
+	glsContactIDRegexp  = regexp.MustCompile(`^[0-9A-Za-z]{10}$`)
+	upsShipperRegexp    = regexp.MustCompile(`^[0-9A-Z]{6}$`)
+	uuidRegexp          = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	digitsRegexp        = regexp.MustCompile(`^\d+$`)
+)
+
+// DHLParameters contains the DHL business customer account (EKP) and the participation codes per product
+type DHLParameters struct {
+	EKP            string            `json:"ekp,omitempty"`            // 10-digit customer number
+	Participations map[string]string `json:"participations,omitempty"` // Product code (e.g. V01PAK) to participation (e.g. 01)
+}
+
+func (m *DHLParameters) CarrierCode() CarrierCode {
+	return CarrierDHL
+}
+
+func (m *DHLParameters) Validate() error {
+	if !ekpRegexp.MatchString(m.EKP) {
+		return carrierParameterError(m, "ekp must have 10 digits")
+	}
+
+	for product, participation := range m.Participations {
+		if !participationRegexp.MatchString(participation) {
+			return carrierParameterError(m, fmt.Sprintf("participation of %s must have 2 characters", product))
+		}
+	}
+	return nil
+}
+
+// BillingNumber returns the 14-digit billing number (EKP + procedure + participation) of a product. procedure is the
+// 2-digit DHL procedure (e.g. 01 for V01PAK). Products without a participation use the default participation 01 of the
+// DHL business customer portal.
+func (m *DHLParameters) BillingNumber(product string, procedure string) (string, error) {
+	if !procedureRegexp.MatchString(procedure) {
+		return "", carrierParameterError(m, fmt.Sprintf("procedure of %s must have 2 digits", product))
+	}
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	participation, ok := m.Participations[product]
+	if !ok {
+		participation = "01"
+	}
+	return m.EKP + procedure + participation, nil
+}
+
+// DPDParameters contains the DPD customer account
+type DPDParameters struct {
+	DelisID        string `json:"delisId,omitempty"`
+	CustomerNumber string `json:"customerNumber,omitempty"`
+	DepotNumber    string `json:"depotNumber,omitempty"` // 4-digit sending depot
+}
+
+func (m *DPDParameters) CarrierCode() CarrierCode {
+	return CarrierDPD
+}
+
+func (m *DPDParameters) Validate() error {
+	if m.DelisID == "" {
+		return carrierParameterError(m, "delisId is required")
+	}
+
+	if m.DepotNumber != "" && !dpdDepotRegexp.MatchString(m.DepotNumber) {
+		return carrierParameterError(m, "depotNumber must have 4 digits")
+	}
+	return nil
+}
+
+// GLSParameters contains the GLS customer account
+type GLSParameters struct {
+	CustomerNumber string `json:"customerNumber,omitempty"`
+	ContactID      string `json:"contactId,omitempty"` // 10-character contact id
+	DepotNumber    string `json:"depotNumber,omitempty"`
+}
+
+func (m *GLSParameters) CarrierCode() CarrierCode {
+	return CarrierGLS
+}
+
+func (m *GLSParameters) Validate() error {
+	if !digitsRegexp.MatchString(m.CustomerNumber) {
+		return carrierParameterError(m, "customerNumber must be numeric")
+	}
+
+	if !glsContactIDRegexp.MatchString(m.ContactID) {
+		return carrierParameterError(m, "contactId must have 10 characters")
+	}
+
+	if m.DepotNumber != "" && !digitsRegexp.MatchString(m.DepotNumber) {
+		return carrierParameterError(m, "depotNumber must be numeric")
+	}
+	return nil
+}
+
+// UPSParameters contains the UPS shipper account
+type UPSParameters struct {
+	ShipperNumber       string `json:"shipperNumber,omitempty"` // 6-character account number
+	AccessLicenseNumber string `json:"accessLicenseNumber,omitempty"`
+}
+
+func (m *UPSParameters) CarrierCode() CarrierCode {
+	return CarrierUPS
+}
+
+func (m *UPSParameters) Validate() error {
+	if !upsShipperRegexp.MatchString(m.ShipperNumber) {
+		return carrierParameterError(m, "shipperNumber must have 6 characters")
+	}
+	return nil
+}
+
+// PostATParameters contains the Österreichische Post customer account
+type PostATParameters struct {
+	ClientID    string `json:"clientId,omitempty"`
+	OrgUnitID   string `json:"orgUnitId,omitempty"`
+	OrgUnitGUID string `json:"orgUnitGuid,omitempty"`
+}
+
+func (m *PostATParameters) CarrierCode() CarrierCode {
+	return CarrierPostAT
+}
+
+func (m *PostATParameters) Validate() error {
+	if !digitsRegexp.MatchString(m.ClientID) {
+		return carrierParameterError(m, "clientId must be numeric")
+	}
+
+	if !digitsRegexp.MatchString(m.OrgUnitID) {
+		return carrierParameterError(m, "orgUnitId must be numeric")
+	}
+
+	if !uuidRegexp.MatchString(m.OrgUnitGUID) {
+		return carrierParameterError(m, "orgUnitGuid must be a UUID")
+	}
+	return nil
+}
+
+// parameterKeys returns the JSON keys of the typed parameters
+func parameterKeys(p CarrierParameters) []string {
+	t := reflect.TypeOf(p)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && t.Field(i).IsExported() {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+func carrierParameterError(p CarrierParameters, msg string) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidCarrierParameter, p.CarrierCode(), msg)
+}
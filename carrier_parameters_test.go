@@ -0,0 +1,62 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCarrier_SetParameters(t *testing.T) {
+	c := &Carrier{Code: CarrierDHL}
+	err := c.SetParameters(&DHLParameters{EKP: "123456789"})
+	if !errors.Is(err, ErrInvalidCarrierParameter) {
+		t.Fatalf("expected invalid ekp, got %v", err)
+	}
+
+	params := &DHLParameters{EKP: "1234567890", Participations: map[string]string{"V01PAK": "02"}}
+	isNoError(t, c.SetParameters(params))
+	isEqual(t, "1234567890", c.Parameters["ekp"])
+	billing, err := params.BillingNumber("V01PAK", "01")
+	isNoError(t, err)
+	isEqual(t, "12345678900102", billing)
+	if _, err = params.BillingNumber("V01PAK", "1"); !errors.Is(err, ErrInvalidCarrierParameter) {
+		t.Fatalf("expected invalid procedure, got %v", err)
+	}
+
+	// Read back from a carrier response
+	b, err := json.Marshal(c)
+	isNoError(t, err)
+	resp := &Carrier{}
+	isNoError(t, json.Unmarshal(b, resp))
+
+	p, err := resp.TypedParameters()
+	isNoError(t, err)
+	isEqual(t, params, p)
+
+	// Untyped and server-side parameters are kept, cleared typed parameters are removed
+	c.Parameters["serverOnly"] = true
+	isNoError(t, c.SetParameters(&DHLParameters{EKP: "0987654321"}))
+	isEqual(t, map[string]any{"ekp": "0987654321", "serverOnly": true}, c.Parameters)
+
+	if err = c.SetParameters(&UPSParameters{ShipperNumber: "A1B2C3"}); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("expected wrong type, got %v", err)
+	}
+
+	// Invalid parameters of a carrier response are rejected
+	c.Parameters["ekp"] = "123"
+	if _, err = c.TypedParameters(); !errors.Is(err, ErrInvalidCarrierParameter) {
+		t.Fatalf("expected invalid ekp, got %v", err)
+	}
+}
+
+func TestAPIContext_GetCarrierParameters_Empty(t *testing.T) {
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("null"))
+	}))
+
+	if _, err := api.GetCarrierParameters(context.Background(), CarrierDHL); !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("expected empty response, got %v", err)
+	}
+}
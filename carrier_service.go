@@ -0,0 +1,586 @@
+package shippinglabel
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DateLayout is the date format of carrier service parameters
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date which is encoded as YYYY-MM-DD
+type Date struct {
+	time.Time
+}
+
+// NewDate creates a date from a time
+func NewDate(t time.Time) Date {
+	return Date{Time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// String returns the date as YYYY-MM-DD
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+// MarshalJSON encodes the date as YYYY-MM-DD
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a YYYY-MM-DD date
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	if s == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// ServiceParameters are the typed parameters of a carrier service
+type ServiceParameters interface {
+	ServiceCode() CarrierServiceCode
+	Validate() error
+}
+
+// NewCarrierService validates the typed parameters and converts them into a CarrierService
+func NewCarrierService(p ServiceParameters) (*CarrierService, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	params, err := toParameterMap(p)
+	if err != nil {
+		return nil, err
+	}
+	return &CarrierService{Service: p.ServiceCode(), Parameters: params}, nil
+}
+
+// DecodeParameters decodes the parameters of the carrier service into v
+func (m *CarrierService) DecodeParameters(v ServiceParameters) error {
+	if v.ServiceCode() != m.Service {
+		return fmt.Errorf("%w: service %s cannot be decoded into %T", ErrWrongType, m.Service, v)
+	}
+
+	if err := fromParameterMap(m.Parameters, v); err != nil {
+		return err
+	}
+	return v.Validate()
+}
+
+// TypedParameters returns the typed parameters of the carrier service. Unknown services are returned as
+// *GenericService with the parameter map.
+func (m *CarrierService) TypedParameters() (ServiceParameters, error) {
+	factory, ok := serviceParameters[m.Service]
+	if !ok {
+		return &GenericService{Code: m.Service, Parameters: m.Parameters}, nil
+	}
+
+	v := factory()
+	if err := m.DecodeParameters(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// serviceParameters contains the typed parameters of all known carrier services
+var serviceParameters = map[CarrierServiceCode]func() ServiceParameters{
+	CarrierServicePreferredNeighbour:    func() ServiceParameters { return &PreferredNeighbour{} },
+	CarrierServicePreferredLocation:     func() ServiceParameters { return &PreferredLocation{} },
+	CarrierServiceVisualCheckOfAge:      func() ServiceParameters { return &VisualCheckOfAge{} },
+	CarrierServiceNamedPersonOnly:       flagService(CarrierServiceNamedPersonOnly),
+	CarrierServiceIdentCheck:            func() ServiceParameters { return &IdentCheck{} },
+	CarrierServicePreferredDay:          func() ServiceParameters { return &PreferredDay{} },
+	CarrierServiceNoNeighbourDelivery:   flagService(CarrierServiceNoNeighbourDelivery),
+	CarrierServiceAdditionalInsurance:   func() ServiceParameters { return &AdditionalInsurance{} },
+	CarrierServiceBulkyGoods:            flagService(CarrierServiceBulkyGoods),
+	CarrierServiceCashOnDelivery:        func() ServiceParameters { return &CashOnDelivery{} },
+	CarrierServicePackagingReturn:       flagService(CarrierServicePackagingReturn),
+	CarrierServiceParcelOutletRouting:   func() ServiceParameters { return &ParcelOutletRouting{} },
+	CarrierServiceFlexDelivery:          flagService(CarrierServiceFlexDelivery),
+	CarrierServiceNextDay:               flagService(CarrierServiceNextDay),
+	CarrierServiceShopReturn:            flagService(CarrierServiceShopReturn),
+	CarrierServiceShopDelivery:          func() ServiceParameters { return &ShopDelivery{} },
+	CarrierServiceIdentPin:              func() ServiceParameters { return &IdentPin{} },
+	CarrierServiceSaturdayDelivery:      flagService(CarrierServiceSaturdayDelivery),
+	CarrierServiceNoShipmentRedirection: flagService(CarrierServiceNoShipmentRedirection),
+	CarrierServiceNoShopAuthorization:   flagService(CarrierServiceNoShopAuthorization),
+	CarrierServiceHazardousGoods:        func() ServiceParameters { return &HazardousGoods{} },
+	CarrierServiceFragile:               flagService(CarrierServiceFragile),
+	CarrierServicePremium:               flagService(CarrierServicePremium),
+	CarrierServicePickup:                func() ServiceParameters { return &Pickup{} },
+	CarrierServiceSignature:             flagService(CarrierServiceSignature),
+}
+
+func flagService(code CarrierServiceCode) func() ServiceParameters {
+	return func() ServiceParameters { return &FlagService{Code: code} }
+}
+
+// GenericService contains the parameters of a carrier service without typed parameters
+type GenericService struct {
+	Code       CarrierServiceCode
+	Parameters map[string]any
+}
+
+func (m *GenericService) ServiceCode() CarrierServiceCode { return m.Code }
+func (m *GenericService) Validate() error                 { return nil }
+
+// MarshalJSON encodes the parameter map
+func (m *GenericService) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Parameters)
+}
+
+// UnmarshalJSON decodes the parameter map
+func (m *GenericService) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &m.Parameters)
+}
+
+// FlagService is a carrier service without parameters
+type FlagService struct {
+	Code CarrierServiceCode `json:"-"`
+}
+
+func (m *FlagService) ServiceCode() CarrierServiceCode { return m.Code }
+func (m *FlagService) Validate() error                 { return nil }
+
+// PreferredNeighbour delivers the parcel to a neighbour if the receiver is absent
+type PreferredNeighbour struct {
+	Neighbour string `json:"neighbour,omitempty"` // Name and address of the neighbour
+}
+
+func (m *PreferredNeighbour) ServiceCode() CarrierServiceCode {
+	return CarrierServicePreferredNeighbour
+}
+
+func (m *PreferredNeighbour) Validate() error {
+	return requireServiceParameter(m, "neighbour", m.Neighbour, 100)
+}
+
+// PreferredLocation deposits the parcel at a location if the receiver is absent
+type PreferredLocation struct {
+	Location string `json:"location,omitempty"` // e.g. garage or terrace
+}
+
+func (m *PreferredLocation) ServiceCode() CarrierServiceCode {
+	return CarrierServicePreferredLocation
+}
+
+func (m *PreferredLocation) Validate() error {
+	return requireServiceParameter(m, "location", m.Location, 100)
+}
+
+// VisualCheckOfAge requires the minimum age of the receiver (16 or 18)
+type VisualCheckOfAge struct {
+	MinimumAge int `json:"minimumAge,omitempty"`
+}
+
+func (m *VisualCheckOfAge) ServiceCode() CarrierServiceCode {
+	return CarrierServiceVisualCheckOfAge
+}
+
+func (m *VisualCheckOfAge) Validate() error {
+	return validateMinimumAge(m, m.MinimumAge)
+}
+
+// IdentCheck checks the identity and age of the receiver
+type IdentCheck struct {
+	MinimumAge  int    `json:"minimumAge,omitempty"`
+	DateOfBirth *Date  `json:"dateOfBirth,omitempty"`
+	FirstName   string `json:"firstName,omitempty"`
+	LastName    string `json:"lastName,omitempty"`
+}
+
+func (m *IdentCheck) ServiceCode() CarrierServiceCode {
+	return CarrierServiceIdentCheck
+}
+
+func (m *IdentCheck) Validate() error {
+	if m.MinimumAge == 0 && m.DateOfBirth == nil {
+		return serviceParameterError(m, "minimumAge or dateOfBirth is required")
+	}
+
+	if m.MinimumAge != 0 {
+		if err := validateMinimumAge(m, m.MinimumAge); err != nil {
+			return err
+		}
+	}
+
+	if m.DateOfBirth != nil && (m.DateOfBirth.IsZero() || m.DateOfBirth.After(time.Now())) {
+		return serviceParameterError(m, "invalid dateOfBirth")
+	}
+	return nil
+}
+
+// PreferredDay delivers the parcel on a specific day
+type PreferredDay struct {
+	Day Date `json:"day"`
+}
+
+func (m *PreferredDay) ServiceCode() CarrierServiceCode {
+	return CarrierServicePreferredDay
+}
+
+func (m *PreferredDay) Validate() error {
+	if m.Day.IsZero() {
+		return serviceParameterError(m, "day is required")
+	}
+	return nil
+}
+
+// AdditionalInsurance insures the parcel up to the amount
+type AdditionalInsurance struct {
+	Amount Amount `json:"amount"`
+}
+
+func (m *AdditionalInsurance) ServiceCode() CarrierServiceCode {
+	return CarrierServiceAdditionalInsurance
+}
+
+func (m *AdditionalInsurance) Validate() error {
+	return validateAmount(m, m.Amount)
+}
+
+// CashOnDelivery collects the amount from the receiver and transfers it to the bank account
+type CashOnDelivery struct {
+	Amount        Amount `json:"amount"`
+	IBAN          string `json:"iban,omitempty"`
+	BIC           string `json:"bic,omitempty"`
+	AccountHolder string `json:"accountHolder,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+}
+
+func (m *CashOnDelivery) ServiceCode() CarrierServiceCode {
+	return CarrierServiceCashOnDelivery
+}
+
+func (m *CashOnDelivery) Validate() error {
+	if err := validateAmount(m, m.Amount); err != nil {
+		return err
+	}
+
+	if !ValidIBAN(m.IBAN) {
+		return serviceParameterError(m, "invalid iban")
+	}
+	return nil
+}
+
+// ParcelOutletRouting routes the parcel to a parcel outlet if the delivery fails and notifies the receiver
+type ParcelOutletRouting struct {
+	Mail string `json:"mail,omitempty"` // Notification address, the receiver mail is used if empty
+}
+
+func (m *ParcelOutletRouting) ServiceCode() CarrierServiceCode {
+	return CarrierServiceParcelOutletRouting
+}
+
+func (m *ParcelOutletRouting) Validate() error {
+	if m.Mail != "" && !strings.Contains(m.Mail, "@") {
+		return serviceParameterError(m, "invalid mail")
+	}
+	return nil
+}
+
+// ShopDelivery delivers the parcel to a parcel shop
+type ShopDelivery struct {
+	ParcelShopID string `json:"parcelShopId,omitempty"`
+}
+
+func (m *ShopDelivery) ServiceCode() CarrierServiceCode {
+	return CarrierServiceShopDelivery
+}
+
+func (m *ShopDelivery) Validate() error {
+	return requireServiceParameter(m, "parcelShopId", m.ParcelShopID, 0)
+}
+
+// IdentPin requires a pin which is sent to the receiver
+type IdentPin struct {
+	Pin string `json:"pin,omitempty"`
+}
+
+func (m *IdentPin) ServiceCode() CarrierServiceCode {
+	return CarrierServiceIdentPin
+}
+
+func (m *IdentPin) Validate() error {
+	if !pinRegexp.MatchString(m.Pin) {
+		return serviceParameterError(m, "pin must have 4 to 8 digits")
+	}
+	return nil
+}
+
+// HazardousGoods declares dangerous goods (e.g. lithium batteries) by their UN number
+type HazardousGoods struct {
+	UNNumber string `json:"unNumber,omitempty"`
+	Class    string `json:"class,omitempty"`
+}
+
+func (m *HazardousGoods) ServiceCode() CarrierServiceCode {
+	return CarrierServiceHazardousGoods
+}
+
+func (m *HazardousGoods) Validate() error {
+	if !unNumberRegexp.MatchString(m.UNNumber) {
+		return serviceParameterError(m, "unNumber must have 4 digits")
+	}
+	return nil
+}
+
+// Pickup orders a pickup of the parcel on the date
+type Pickup struct {
+	Date Date `json:"date"`
+}
+
+func (m *Pickup) ServiceCode() CarrierServiceCode {
+	return CarrierServicePickup
+}
+
+func (m *Pickup) Validate() error {
+	if m.Date.IsZero() {
+		return serviceParameterError(m, "date is required")
+	}
+	return nil
+}
+
+// CONSTRUCTORS
+
+// NewPreferredNeighbour creates a PREFERRED_NEIGHBOUR service
+func NewPreferredNeighbour(neighbour string) (*CarrierService, error) {
+	return NewCarrierService(&PreferredNeighbour{Neighbour: neighbour})
+}
+
+// NewPreferredLocation creates a PREFERRED_LOCATION service
+func NewPreferredLocation(location string) (*CarrierService, error) {
+	return NewCarrierService(&PreferredLocation{Location: location})
+}
+
+// NewVisualCheckOfAge creates a VISUAL_CHECK_OF_AGE service
+func NewVisualCheckOfAge(minAge int) (*CarrierService, error) {
+	return NewCarrierService(&VisualCheckOfAge{MinimumAge: minAge})
+}
+
+// NewNamedPersonOnly creates a NAMED_PERSON_ONLY service
+func NewNamedPersonOnly() *CarrierService {
+	return &CarrierService{Service: CarrierServiceNamedPersonOnly}
+}
+
+// NewIdentCheck creates an IDENT_CHECK service. dob can be zero if only the minimum age is checked.
+func NewIdentCheck(minAge int, dob time.Time) (*CarrierService, error) {
+	p := &IdentCheck{MinimumAge: minAge}
+	if !dob.IsZero() {
+		d := NewDate(dob)
+		p.DateOfBirth = &d
+	}
+	return NewCarrierService(p)
+}
+
+// NewPreferredDay creates a PREFERRED_DAY service
+func NewPreferredDay(day time.Time) (*CarrierService, error) {
+	return NewCarrierService(&PreferredDay{Day: NewDate(day)})
+}
+
+// NewNoNeighbourDelivery creates a NO_NEIGHBOUR_DELIVERY service
+func NewNoNeighbourDelivery() *CarrierService {
+	return &CarrierService{Service: CarrierServiceNoNeighbourDelivery}
+}
+
+// NewAdditionalInsurance creates an ADDITIONAL_INSURANCE service
+func NewAdditionalInsurance(amount Amount) (*CarrierService, error) {
+	return NewCarrierService(&AdditionalInsurance{Amount: amount})
+}
+
+// NewBulkyGoods creates a BULKY_GOODS service
+func NewBulkyGoods() *CarrierService {
+	return &CarrierService{Service: CarrierServiceBulkyGoods}
+}
+
+// NewCashOnDelivery creates a CASH_ON_DELIVERY service
+func NewCashOnDelivery(amount Amount, iban string) (*CarrierService, error) {
+	return NewCarrierService(&CashOnDelivery{Amount: amount, IBAN: iban})
+}
+
+// NewPackagingReturn creates a PACKAGING_RETURN service
+func NewPackagingReturn() *CarrierService {
+	return &CarrierService{Service: CarrierServicePackagingReturn}
+}
+
+// NewParcelOutletRouting creates a PARCEL_OUTLET_ROUTING service. mail can be empty.
+func NewParcelOutletRouting(mail string) (*CarrierService, error) {
+	return NewCarrierService(&ParcelOutletRouting{Mail: mail})
+}
+
+// NewFlexDelivery creates a FLEX_DELIVERY service
+func NewFlexDelivery() *CarrierService {
+	return &CarrierService{Service: CarrierServiceFlexDelivery}
+}
+
+// NewNextDay creates a NEXT_DAY service
+func NewNextDay() *CarrierService {
+	return &CarrierService{Service: CarrierServiceNextDay}
+}
+
+// NewShopReturn creates a SHOP_RETURN service
+func NewShopReturn() *CarrierService {
+	return &CarrierService{Service: CarrierServiceShopReturn}
+}
+
+// NewShopDelivery creates a SHOP_DELIVERY service
+func NewShopDelivery(parcelShopID string) (*CarrierService, error) {
+	return NewCarrierService(&ShopDelivery{ParcelShopID: parcelShopID})
+}
+
+// NewIdentPin creates an IDENT_PIN service
+func NewIdentPin(pin string) (*CarrierService, error) {
+	return NewCarrierService(&IdentPin{Pin: pin})
+}
+
+// NewSaturdayDelivery creates a SATURDAY_DELIVERY service
+func NewSaturdayDelivery() *CarrierService {
+	return &CarrierService{Service: CarrierServiceSaturdayDelivery}
+}
+
+// NewNoShipmentRedirection creates a NO_SHIPMENT_REDIRECTION service
+func NewNoShipmentRedirection() *CarrierService {
+	return &CarrierService{Service: CarrierServiceNoShipmentRedirection}
+}
+
+// NewNoShopAuthorization creates a NO_SHOP_AUTHORIZATION service
+func NewNoShopAuthorization() *CarrierService {
+	return &CarrierService{Service: CarrierServiceNoShopAuthorization}
+}
+
+// NewHazardousGoods creates a HAZARDOUS_GOODS service
+func NewHazardousGoods(unNumber string) (*CarrierService, error) {
+	return NewCarrierService(&HazardousGoods{UNNumber: unNumber})
+}
+
+// NewFragile creates a FRAGILE service
+func NewFragile() *CarrierService {
+	return &CarrierService{Service: CarrierServiceFragile}
+}
+
+// NewPremium creates a PREMIUM service
+func NewPremium() *CarrierService {
+	return &CarrierService{Service: CarrierServicePremium}
+}
+
+// NewPickup creates a PICKUP service
+func NewPickup(date time.Time) (*CarrierService, error) {
+	return NewCarrierService(&Pickup{Date: NewDate(date)})
+}
+
+// NewSignature creates a SIGNATURE service
+func NewSignature() *CarrierService {
+	return &CarrierService{Service: CarrierServiceSignature}
+}
+
+// VALIDATION
+
+var (
+	pinRegexp      = regexp.MustCompile(`^\d{4,8}$`)
+	unNumberRegexp = regexp.MustCompile(`^\d{4}$`)
+	ibanRegexp     = regexp.MustCompile(`^[A-Z]{2}\d{2}[A-Z0-9]{11,30}$`)
+)
+
+// ValidIBAN checks the format and the checksum (ISO 13616) of an IBAN. Spaces are ignored.
+func ValidIBAN(iban string) bool {
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if !ibanRegexp.MatchString(iban) {
+		return false
+	}
+
+	// Move the country code and checksum to the end and convert the letters to numbers (A = 10, ..., Z = 35)
+	var sb strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteString(fmt.Sprint(r - 'A' + 10))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	return ok && n.Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func serviceParameterError(p ServiceParameters, msg string) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidServiceParameter, p.ServiceCode(), msg)
+}
+
+func requireServiceParameter(p ServiceParameters, name string, value string, maxLen int) error {
+	if strings.TrimSpace(value) == "" {
+		return serviceParameterError(p, name+" is required")
+	}
+
+	if maxLen > 0 && len([]rune(value)) > maxLen {
+		return serviceParameterError(p, fmt.Sprintf("%s exceeds %d characters", name, maxLen))
+	}
+	return nil
+}
+
+func validateMinimumAge(p ServiceParameters, age int) error {
+	if age != 16 && age != 18 {
+		return serviceParameterError(p, "minimumAge must be 16 or 18")
+	}
+	return nil
+}
+
+func validateAmount(p ServiceParameters, a Amount) error {
+	if a.Value <= 0 {
+		return serviceParameterError(p, "amount must be positive")
+	}
+
+	if len(a.Currency) != 3 {
+		return serviceParameterError(p, "currency must be an ISO 4217 code")
+	}
+	return nil
+}
+
+// toParameterMap converts a struct into a parameter map via its JSON encoding
+func toParameterMap(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var params map[string]any
+	if err = json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+
+	if len(params) == 0 {
+		return nil, nil
+	}
+	return params, nil
+}
+
+// fromParameterMap converts a parameter map into a struct via its JSON encoding
+func fromParameterMap(params map[string]any, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,69 @@
+package shippinglabel
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewCashOnDelivery(t *testing.T) {
+	svc, err := NewCashOnDelivery(Amount{Value: 12.5, Currency: "EUR"}, "DE89 3704 0044 0532 0130 00")
+	isNoError(t, err)
+	isEqual(t, "DE89 3704 0044 0532 0130 00", svc.Parameters["iban"])
+
+	// Round trip through JSON
+	b, err := json.Marshal(svc)
+	isNoError(t, err)
+	decoded := &CarrierService{}
+	isNoError(t, json.Unmarshal(b, decoded))
+
+	p, err := decoded.TypedParameters()
+	isNoError(t, err)
+	cod, ok := p.(*CashOnDelivery)
+	if !ok {
+		t.Fatalf("expected *CashOnDelivery, got %T", p)
+	}
+	isEqual(t, Amount{Value: 12.5, Currency: "EUR"}, cod.Amount)
+
+	_, err = NewCashOnDelivery(Amount{Value: 12.5, Currency: "EUR"}, "DE89370400440532013001")
+	if !errors.Is(err, ErrInvalidServiceParameter) {
+		t.Fatalf("expected invalid iban, got %v", err)
+	}
+}
+
+func TestNewPreferredDay(t *testing.T) {
+	svc, err := NewPreferredDay(time.Date(2023, 2, 3, 15, 0, 0, 0, time.Local))
+	isNoError(t, err)
+
+	b, err := json.Marshal(svc)
+	isNoError(t, err)
+	isEqual(t, `{"service":"PREFERRED_DAY","parameters":{"day":"2023-02-03"}}`, string(b))
+
+	p := &PreferredDay{}
+	isNoError(t, svc.DecodeParameters(p))
+	isEqual(t, "2023-02-03", p.Day.String())
+	isEqual(t, ErrWrongType, errors.Unwrap(svc.DecodeParameters(&Pickup{})))
+}
+
+func TestCarrierService_TypedParameters(t *testing.T) {
+	_, err := NewIdentCheck(21, time.Time{})
+	if !errors.Is(err, ErrInvalidServiceParameter) {
+		t.Fatalf("expected invalid age, got %v", err)
+	}
+
+	p, err := NewSignature().TypedParameters()
+	isNoError(t, err)
+	isEqual(t, CarrierServiceSignature, p.ServiceCode())
+
+	unknown := &CarrierService{Service: "GREEN_DELIVERY", Parameters: map[string]any{"level": "1"}}
+	p, err = unknown.TypedParameters()
+	isNoError(t, err)
+	isEqual(t, unknown.Parameters, p.(*GenericService).Parameters)
+
+	for code := range serviceParameters {
+		if serviceParameters[code]().ServiceCode() != code {
+			t.Fatalf("wrong typed parameters for %s", code)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package shippinglabel
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -89,7 +90,9 @@ func (c *Client) send(ctx context.Context, req *request) error {
 	// Check status code
 	if resp.StatusCode >= 400 {
 		em := &Error{}
-		if err = json.NewDecoder(resp.Body).Decode(em); err != nil {
+		if err = json.NewDecoder(resp.Body).Decode(em); errors.Is(err, io.EOF) {
+			return &StatusError{StatusCode: resp.StatusCode}
+		} else if err != nil {
 			return err
 		}
 		return em
@@ -2,6 +2,9 @@ package shippinglabel
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
 	"os"
 	"reflect"
 	"sync"
@@ -61,6 +64,31 @@ func TestClient_Error(t *testing.T) {
 	}
 }
 
+func TestAPIContext_ErrorResponse(t *testing.T) {
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/parcels/1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"parcel not found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	// Error responses with a body are still returned as *Error
+	_, err := api.GetParcel(context.Background(), 1)
+	if e, ok := err.(*Error); !ok || e.Message != "parcel not found" {
+		t.Fatalf("expected error of type *Error, got %T: %v", err, err)
+	}
+
+	// Error responses without a body no longer return io.EOF
+	_, err = api.GetParcel(context.Background(), 2)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway || errors.Is(err, io.EOF) {
+		t.Fatalf("expected status error, got %T: %v", err, err)
+	}
+	isEqual(t, "502 Bad Gateway", err.Error())
+}
+
 func TestClient_RefreshToken(t *testing.T) {
 	initClientAndAPIContext(t)
 	api.token.ExpiresIn = -5000
@@ -287,13 +315,3 @@ func isNil(v any) bool {
 	kind := value.Kind()
 	return kind >= reflect.Chan && kind <= reflect.Slice && value.IsNil()
 }
-
-func contains[T comparable](s []T, v T) bool {
-	for _, t := range s {
-		if t == v {
-			return true
-		}
-	}
-
-	return false
-}
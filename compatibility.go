@@ -0,0 +1,213 @@
+package shippinglabel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServiceCompatibility contains the carrier services which are supported by a carrier. ProductServices restricts the
+// services of single products, products without an entry support all carrier services.
+type ServiceCompatibility struct {
+	Services        []CarrierServiceCode
+	ProductServices map[string][]CarrierServiceCode
+}
+
+// ServiceConflict is a combination of two carrier services which cannot be booked together
+type ServiceConflict struct {
+	A, B   CarrierServiceCode
+	Reason string
+}
+
+// CompatibilityMatrix contains the supported carrier services and the conflicting combinations
+type CompatibilityMatrix struct {
+	Carriers  map[CarrierCode]*ServiceCompatibility
+	Conflicts []ServiceConflict
+}
+
+// DefaultCompatibilityMatrix is the compatibility table of the carrier services. Carriers without an entry (e.g.
+// Deutsche Post) are unknown, their services are not checked.
+var DefaultCompatibilityMatrix = &CompatibilityMatrix{
+	Carriers: map[CarrierCode]*ServiceCompatibility{
+		CarrierDHL: {
+			Services: []CarrierServiceCode{
+				CarrierServicePreferredNeighbour, CarrierServicePreferredLocation, CarrierServiceVisualCheckOfAge,
+				CarrierServiceNamedPersonOnly, CarrierServiceIdentCheck, CarrierServicePreferredDay,
+				CarrierServiceNoNeighbourDelivery, CarrierServiceAdditionalInsurance, CarrierServiceBulkyGoods,
+				CarrierServiceCashOnDelivery, CarrierServicePackagingReturn, CarrierServiceParcelOutletRouting,
+				CarrierServicePremium, CarrierServiceSignature,
+			},
+			ProductServices: map[string][]CarrierServiceCode{
+				// Warenpost
+				"V62WP": {CarrierServicePreferredNeighbour, CarrierServicePreferredLocation, CarrierServiceParcelOutletRouting},
+				// Warenpost International
+				"V66WPI": {CarrierServicePremium},
+				// Paket International
+				"V53WPAK": {
+					CarrierServiceAdditionalInsurance, CarrierServiceBulkyGoods, CarrierServiceCashOnDelivery,
+					CarrierServicePremium,
+				},
+			},
+		},
+		CarrierDPD: {
+			Services: []CarrierServiceCode{
+				CarrierServicePreferredNeighbour, CarrierServicePreferredLocation, CarrierServicePreferredDay,
+				CarrierServiceAdditionalInsurance, CarrierServiceCashOnDelivery, CarrierServiceNextDay,
+				CarrierServiceShopReturn, CarrierServiceShopDelivery, CarrierServiceIdentPin,
+				CarrierServiceSaturdayDelivery, CarrierServiceHazardousGoods, CarrierServicePickup,
+			},
+		},
+		CarrierGLS: {
+			Services: []CarrierServiceCode{
+				CarrierServicePreferredNeighbour, CarrierServicePreferredLocation, CarrierServiceIdentCheck,
+				CarrierServiceAdditionalInsurance, CarrierServiceCashOnDelivery, CarrierServiceFlexDelivery,
+				CarrierServiceNextDay, CarrierServiceShopReturn, CarrierServiceShopDelivery,
+				CarrierServiceSaturdayDelivery, CarrierServiceHazardousGoods, CarrierServicePickup,
+				CarrierServiceSignature,
+			},
+		},
+		CarrierHermes: {
+			Services: []CarrierServiceCode{
+				CarrierServiceVisualCheckOfAge, CarrierServiceNamedPersonOnly, CarrierServiceIdentCheck,
+				CarrierServiceNoNeighbourDelivery, CarrierServiceAdditionalInsurance, CarrierServiceBulkyGoods,
+				CarrierServiceCashOnDelivery, CarrierServiceShopDelivery, CarrierServiceNoShipmentRedirection,
+				CarrierServiceNoShopAuthorization, CarrierServicePickup,
+			},
+		},
+		CarrierUPS: {
+			Services: []CarrierServiceCode{
+				CarrierServiceVisualCheckOfAge, CarrierServiceAdditionalInsurance, CarrierServiceCashOnDelivery,
+				CarrierServiceShopDelivery, CarrierServiceSaturdayDelivery, CarrierServiceHazardousGoods,
+				CarrierServicePickup, CarrierServiceSignature,
+			},
+		},
+		CarrierPostAT: {
+			Services: []CarrierServiceCode{
+				CarrierServicePreferredNeighbour, CarrierServiceVisualCheckOfAge, CarrierServiceAdditionalInsurance,
+				CarrierServiceBulkyGoods, CarrierServiceCashOnDelivery, CarrierServiceShopDelivery,
+				CarrierServiceFragile, CarrierServiceSignature,
+			},
+		},
+		CarrierDHLExpress: {
+			Services: []CarrierServiceCode{
+				CarrierServiceAdditionalInsurance, CarrierServiceSaturdayDelivery, CarrierServiceHazardousGoods,
+				CarrierServicePickup, CarrierServiceSignature,
+			},
+		},
+	},
+	Conflicts: []ServiceConflict{
+		{CarrierServicePreferredNeighbour, CarrierServiceNoNeighbourDelivery, "a neighbour delivery is excluded"},
+		{CarrierServicePreferredNeighbour, CarrierServiceNamedPersonOnly, "only the receiver may accept the parcel"},
+		{CarrierServicePreferredNeighbour, CarrierServiceIdentCheck, "only the receiver may accept the parcel"},
+		{CarrierServicePreferredNeighbour, CarrierServicePreferredLocation, "only one alternative delivery is possible"},
+		{CarrierServicePreferredLocation, CarrierServiceNamedPersonOnly, "only the receiver may accept the parcel"},
+		{CarrierServicePreferredLocation, CarrierServiceIdentCheck, "only the receiver may accept the parcel"},
+		{CarrierServicePreferredLocation, CarrierServiceVisualCheckOfAge, "the age must be checked on delivery"},
+		{CarrierServicePreferredLocation, CarrierServiceCashOnDelivery, "the amount must be collected on delivery"},
+		{CarrierServiceNamedPersonOnly, CarrierServiceParcelOutletRouting, "the parcel must not be handed to an outlet"},
+		{CarrierServiceVisualCheckOfAge, CarrierServiceIdentCheck, "the ident check includes the age check"},
+		{CarrierServiceShopDelivery, CarrierServicePreferredNeighbour, "the parcel is delivered to a shop"},
+		{CarrierServiceShopDelivery, CarrierServicePreferredLocation, "the parcel is delivered to a shop"},
+		{CarrierServiceShopDelivery, CarrierServicePreferredDay, "the parcel is delivered to a shop"},
+		{CarrierServiceShopDelivery, CarrierServiceSaturdayDelivery, "the parcel is delivered to a shop"},
+		{CarrierServiceShopDelivery, CarrierServiceNoShopAuthorization, "the parcel is delivered to a shop"},
+		{CarrierServicePreferredDay, CarrierServiceNextDay, "only one delivery day is possible"},
+		{CarrierServicePreferredDay, CarrierServiceSaturdayDelivery, "only one delivery day is possible"},
+	},
+}
+
+// SupportedServices returns the carrier services of a carrier product. An empty product returns all services of the
+// carrier.
+func (m *CompatibilityMatrix) SupportedServices(carrier CarrierCode, product string) []CarrierServiceCode {
+	c, ok := m.Carriers[carrier]
+	if !ok || c == nil {
+		return nil
+	}
+
+	if s, ok := c.ProductServices[product]; ok && product != "" {
+		return s
+	}
+	return c.Services
+}
+
+// Supports returns whether the carrier product supports the service
+func (m *CompatibilityMatrix) Supports(carrier CarrierCode, product string, service CarrierServiceCode) bool {
+	return contains(m.SupportedServices(carrier, product), service)
+}
+
+// Lookup returns the matrix as ServiceLookup for the RequireServices rule
+func (m *CompatibilityMatrix) Lookup() ServiceLookup {
+	return func(carrier CarrierCode, p *Product) []CarrierServiceCode {
+		return m.SupportedServices(carrier, p.Product)
+	}
+}
+
+// Conflict returns the conflict of two services or nil
+func (m *CompatibilityMatrix) Conflict(a, b CarrierServiceCode) *ServiceConflict {
+	for i, c := range m.Conflicts {
+		if (c.A == a && c.B == b) || (c.A == b && c.B == a) {
+			return &m.Conflicts[i]
+		}
+	}
+	return nil
+}
+
+// ServiceError contains all invalid carrier services of a shipment
+type ServiceError struct {
+	Problems []string
+}
+
+func (m *ServiceError) Error() string {
+	return "invalid carrier services: " + strings.Join(m.Problems, "; ")
+}
+
+// CheckServices checks the carrier services of the shipment carrier. It rejects unsupported services, duplicates and
+// conflicting combinations with a *ServiceError. A shipment without a carrier has no services to check.
+func (m *CompatibilityMatrix) CheckServices(s *Shipment) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	if s.Carrier == nil {
+		return nil
+	}
+
+	c := s.Carrier
+	_, known := m.Carriers[c.Code]
+	problems := make([]string, 0)
+	seen := make([]CarrierServiceCode, 0, len(c.Services))
+	for _, svc := range c.Services {
+		if svc == nil {
+			continue
+		}
+
+		if contains(seen, svc.Service) {
+			problems = append(problems, fmt.Sprintf("%s is booked more than once", svc.Service))
+			continue
+		}
+
+		if known && !m.Supports(c.Code, c.Product, svc.Service) {
+			if c.Product != "" {
+				problems = append(problems, fmt.Sprintf("%s is not available for %s product %s", svc.Service, c.Code, c.Product))
+			} else {
+				problems = append(problems, fmt.Sprintf("%s is not available for %s", svc.Service, c.Code))
+			}
+		}
+
+		for _, other := range seen {
+			if conflict := m.Conflict(other, svc.Service); conflict != nil {
+				problems = append(problems, fmt.Sprintf("%s conflicts with %s: %s", other, svc.Service, conflict.Reason))
+			}
+		}
+		seen = append(seen, svc.Service)
+	}
+
+	if len(problems) > 0 {
+		return &ServiceError{Problems: problems}
+	}
+	return nil
+}
+
+// CheckServices checks the carrier services of a shipment against the DefaultCompatibilityMatrix
+func CheckServices(s *Shipment) error {
+	return DefaultCompatibilityMatrix.CheckServices(s)
+}
@@ -0,0 +1,45 @@
+package shippinglabel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckServices(t *testing.T) {
+	s := &Shipment{Carrier: &Carrier{Code: CarrierDHL, Product: "V01PAK", Services: []*CarrierService{
+		{Service: CarrierServicePreferredNeighbour},
+		NewNoNeighbourDelivery(),
+	}}}
+
+	var svcErr *ServiceError
+	if err := CheckServices(s); !errors.As(err, &svcErr) || len(svcErr.Problems) != 1 {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	s.Carrier.Services = []*CarrierService{NewNamedPersonOnly(), NewSaturdayDelivery()}
+	if err := CheckServices(s); !errors.As(err, &svcErr) || len(svcErr.Problems) != 1 {
+		t.Fatalf("expected unsupported service, got %v", err)
+	}
+
+	s.Carrier.Services = []*CarrierService{NewNamedPersonOnly(), NewPremium()}
+	isNoError(t, CheckServices(s))
+
+	// Warenpost supports only a few services
+	s.Carrier.Product = "V62WP"
+	if err := CheckServices(s); err == nil {
+		t.Fatalf("expected unsupported services")
+	}
+
+	// The services of unknown carriers are not checked
+	s.Carrier = &Carrier{Code: CarrierDP, Services: []*CarrierService{NewPremium()}}
+	isNoError(t, CheckServices(s))
+
+	if err := CheckServices(nil); !errors.Is(err, ErrRequiredShipment) {
+		t.Fatalf("expected required shipment, got %v", err)
+	}
+
+	lookup := DefaultCompatibilityMatrix.Lookup()
+	_, err := SelectProduct(testMetadataSnapshot(t).Carriers, &Shipment{}, OnlyCarriers(CarrierDHL),
+		RequireServices(lookup, CarrierServiceCashOnDelivery, CarrierServiceNamedPersonOnly))
+	isNoError(t, err)
+}
@@ -0,0 +1,158 @@
+package shippinglabel
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// callingCodes contains the international calling codes of the countries
+var callingCodes = map[string]string{
+	"AE": "971", "AT": "43", "AU": "61", "BE": "32", "BG": "359", "BR": "55", "CA": "1", "CH": "41", "CN": "86",
+	"CY": "357", "CZ": "420", "DE": "49", "DK": "45", "EE": "372", "ES": "34", "FI": "358", "FR": "33", "GB": "44",
+	"GR": "30", "HK": "852", "HR": "385", "HU": "36", "IE": "353", "IL": "972", "IN": "91", "IS": "354", "IT": "39",
+	"JP": "81", "KR": "82", "LI": "423", "LT": "370", "LU": "352", "LV": "371", "MC": "377", "MT": "356", "MX": "52",
+	"NL": "31", "NO": "47", "NZ": "64", "PL": "48", "PT": "351", "RO": "40", "RS": "381", "RU": "7", "SE": "46",
+	"SG": "65", "SI": "386", "SK": "421", "SM": "378", "TR": "90", "UA": "380", "US": "1", "VA": "39", "ZA": "27",
+}
+
+// keepTrunkPrefix contains the countries whose national numbers keep the leading 0 (e.g. IT +39 06 ...)
+var keepTrunkPrefix = map[string]bool{"IT": true, "SM": true, "VA": true}
+
+var (
+	phoneTrunkRegexp = regexp.MustCompile(`\(0\)`)
+	phoneCharsRegexp = regexp.MustCompile(`[\s\-./()]`)
+	e164Regexp       = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+)
+
+// NormalizePhone converts a phone number into the E.164 format (e.g. "030 1234567" in DE -> +49301234567). country is
+// used for national numbers and can be an alpha-2 or alpha-3 code or a name. The errors do not contain the number.
+func NormalizePhone(phone string, country string) (string, error) {
+	p := phoneTrunkRegexp.ReplaceAllString(strings.TrimSpace(phone), "")
+	p = phoneCharsRegexp.ReplaceAllString(p, "")
+
+	switch {
+	case strings.HasPrefix(p, "+"), strings.HasPrefix(p, "00"):
+		p = "+" + stripTrunkPrefix(strings.TrimPrefix(strings.TrimPrefix(p, "+"), "00"))
+	default:
+		code, err := NormalizeCountry(country)
+		if err != nil {
+			return "", fmt.Errorf("%w: a national number needs a country", ErrInvalidPhone)
+		}
+
+		cc, ok := callingCodes[code]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown calling code of %s", ErrInvalidPhone, code)
+		}
+
+		if cc == "1" && len(p) == 11 && strings.HasPrefix(p, "1") {
+			p = p[1:]
+		} else if !keepTrunkPrefix[code] {
+			p = strings.TrimPrefix(p, "0")
+		}
+		p = "+" + cc + p
+	}
+
+	if !e164Regexp.MatchString(p) {
+		return "", fmt.Errorf("%w: not an E.164 number", ErrInvalidPhone)
+	}
+	return p, nil
+}
+
+// stripTrunkPrefix removes the national trunk prefix after the calling code of an international number without "+"
+// (e.g. 49030... -> 4930...) unless the country keeps it
+func stripTrunkPrefix(p string) string {
+	for code, cc := range callingCodes {
+		if strings.HasPrefix(p, cc+"0") && !keepTrunkPrefix[code] {
+			return cc + p[len(cc)+1:]
+		}
+	}
+	return p
+}
+
+// ValidateMail checks the syntax of a mail address without a display name (e.g. max@example.com)
+func ValidateMail(address string) error {
+	a, err := mail.ParseAddress(address)
+	if err != nil || a.Name != "" || a.Address != address {
+		return fmt.Errorf("%w: %q", ErrInvalidMail, address)
+	}
+
+	at := strings.LastIndex(address, "@")
+	domain := address[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return fmt.Errorf("%w: %q", ErrInvalidMail, address)
+	}
+	return nil
+}
+
+// ContactOptions configures NormalizeContact
+type ContactOptions struct {
+	Consent     bool // The customer agreed that the mail and the phone number are passed to the carrier
+	DropInvalid bool // Invalid phone numbers and mails are removed instead of returning an error
+}
+
+// NormalizeContact converts the phone number of the address into E.164 and validates the mail. Without consent the mail
+// and the phone number are removed (GDPR). Invalid values are returned with an *AddressError unless DropInvalid is set.
+// Neither the problems nor the change reasons contain the invalid values and the Old value of a removed mail or phone
+// number is RedactedContact.
+func NormalizeContact(a *Address, opts ContactOptions) ([]AddressChange, error) {
+	if a == nil {
+		return nil, ErrRequiredAddress
+	}
+
+	n := &addressNormalizer{a: a}
+	if !opts.Consent {
+		n.removeContact("Mail", &a.Mail, "no consent")
+		n.removeContact("Phone", &a.Phone, "no consent")
+		return n.changes, nil
+	}
+
+	problems := make([]string, 0)
+	if a.Phone != "" {
+		phone, err := NormalizePhone(a.Phone, a.Country)
+		switch {
+		case err == nil:
+			n.set("Phone", &a.Phone, phone, "E.164")
+		case opts.DropInvalid:
+			n.removeContact("Phone", &a.Phone, err.Error())
+		default:
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if a.Mail != "" {
+		err := ValidateMail(strings.TrimSpace(a.Mail))
+		switch {
+		case err == nil:
+			n.set("Mail", &a.Mail, strings.TrimSpace(a.Mail), "whitespace")
+		case opts.DropInvalid:
+			n.removeContact("Mail", &a.Mail, ErrInvalidMail.Error())
+		default:
+			problems = append(problems, ErrInvalidMail.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return n.changes, &AddressError{Problems: problems}
+	}
+	return n.changes, nil
+}
+
+// StripContact removes the mail and the phone number of the address. The changes do not contain the removed values.
+func StripContact(a *Address) []AddressChange {
+	changes, _ := NormalizeContact(a, ContactOptions{})
+	return changes
+}
+
+// RedactedContact replaces the Old value of a removed mail or phone number in an AddressChange
+const RedactedContact = "[REDACTED]"
+
+// removeContact clears a contact field without recording the removed value in the change
+func (n *addressNormalizer) removeContact(field string, ptr *string, reason string) {
+	if *ptr == "" {
+		return
+	}
+	n.changes = append(n.changes, AddressChange{Field: field, Old: RedactedContact, Reason: reason})
+	*ptr = ""
+}
@@ -0,0 +1,94 @@
+package shippinglabel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone, country, expected string
+	}{
+		{"030 1234567", "DE", "+49301234567"},
+		{"+49 (0)30 123-4567", "DE", "+49301234567"},
+		{"0049 30 1234567", "", "+49301234567"},
+		{"+49 030 1234567", "", "+49301234567"},
+		{"0049 030 1234567", "", "+49301234567"},
+		{"+39 06 1234 5678", "", "+390612345678"},
+		{"01 234 56 78", "Österreich", "+4312345678"},
+		{"06 1234 5678", "IT", "+390612345678"},
+		{"(555) 123-4567", "US", "+15551234567"},
+		{"1 555 123 4567", "USA", "+15551234567"},
+		{"020 7946 0018", "GB", "+442079460018"},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.phone, tt.country)
+		isNoError(t, err)
+		if got != tt.expected {
+			t.Errorf("NormalizePhone(%q, %s) = %s, expected %s", tt.phone, tt.country, got, tt.expected)
+		}
+	}
+
+	for _, phone := range []string{"123", "030 1234567 ext", "+0 123456789"} {
+		if _, err := NormalizePhone(phone, "DE"); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("%q: expected invalid phone, got %v", phone, err)
+		}
+	}
+}
+
+func TestValidateMail(t *testing.T) {
+	for _, m := range []string{"max@example.com", "max.mustermann+shop@mail.example.de"} {
+		isNoError(t, ValidateMail(m))
+	}
+
+	for _, m := range []string{"", "max", "max@", "max@example", "Max <max@example.com>", "max@@example.com", "max @example.com"} {
+		if err := ValidateMail(m); !errors.Is(err, ErrInvalidMail) {
+			t.Errorf("%q: expected invalid mail, got %v", m, err)
+		}
+	}
+}
+
+func TestNormalizeContact(t *testing.T) {
+	a := &Address{Country: "DE", Phone: "030 1234567", Mail: " max@example.com "}
+	changes, err := NormalizeContact(a, ContactOptions{Consent: true})
+	isNoError(t, err)
+	isEqual(t, a.Phone, "+49301234567")
+	isEqual(t, a.Mail, "max@example.com")
+	isEqual(t, len(changes), 2)
+
+	a.Mail = "max@example"
+	var addrErr *AddressError
+	if _, err = NormalizeContact(a, ContactOptions{Consent: true}); !errors.As(err, &addrErr) {
+		t.Fatalf("expected address error, got %v", err)
+	}
+
+	a.Mail = " max@example "
+	changes, err = NormalizeContact(a, ContactOptions{Consent: true, DropInvalid: true})
+	isNoError(t, err)
+	isEqual(t, a.Mail, "")
+	isEqual(t, a.Phone, "+49301234567")
+	isEqual(t, []AddressChange{{Field: "Mail", Old: RedactedContact, Reason: ErrInvalidMail.Error()}}, changes)
+
+	// The reason does not contain the invalid number
+	a.Phone = "030 1234567 ext"
+	changes, err = NormalizeContact(a, ContactOptions{Consent: true, DropInvalid: true})
+	isNoError(t, err)
+	isEqual(t, len(changes), 1)
+	if strings.Contains(changes[0].Reason, "1234567") {
+		t.Errorf("reason contains the phone number: %s", changes[0].Reason)
+	}
+
+	if changes[0].Old != RedactedContact || changes[0].New != "" {
+		t.Errorf("change contains the phone number: %+v", changes[0])
+	}
+
+	a.Phone, a.Mail = "+49301234567", "max@example.com"
+	changes = StripContact(a)
+	isEqual(t, a.Phone, "")
+	isEqual(t, []AddressChange{
+		{Field: "Mail", Old: RedactedContact, Reason: "no consent"},
+		{Field: "Phone", Old: RedactedContact, Reason: "no consent"},
+	}, changes)
+}
@@ -0,0 +1,52 @@
+package shippinglabel
+
+import "github.com/dewaco/shippinglabel/country"
+
+// IsCountryCode returns whether code is an ISO 3166-1 alpha-2 code (e.g. DE)
+func IsCountryCode(code string) bool {
+	return country.IsCode(code)
+}
+
+// NormalizeCountry returns the alpha-2 code of an alpha-2 or alpha-3 code or a country name. Unknown countries are
+// rejected with country.ErrUnknown.
+func NormalizeCountry(s string) (string, error) {
+	return country.Normalize(s)
+}
+
+// ClassifyRoute returns the zone of a shipment from sender to receiver, see country.Classify
+func ClassifyRoute(sender, receiver *Address) (country.Zone, error) {
+	if sender == nil || receiver == nil {
+		return "", ErrRequiredAddress
+	}
+
+	from := country.Location{Country: sender.Country, PostalCode: sender.PostalCode}
+	to := country.Location{Country: receiver.Country, PostalCode: receiver.PostalCode}
+	return country.Classify(from, to)
+}
+
+// RequiresCustoms returns whether a shipment from sender to receiver needs customs documents
+func RequiresCustoms(sender, receiver *Address) (bool, error) {
+	zone, err := ClassifyRoute(sender, receiver)
+	if err != nil {
+		return false, err
+	}
+	return zone.RequiresCustoms(), nil
+}
+
+// CheckCustoms returns ErrRequiredCustoms if the shipment needs customs documents but has no customs items. It can be
+// used as ShipmentCheck.
+func CheckCustoms(s *Shipment) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	required, err := RequiresCustoms(s.Sender, s.Receiver)
+	if err != nil {
+		return err
+	}
+
+	if required && (s.Customs == nil || len(s.Customs.Items) == 0) {
+		return ErrRequiredCustoms
+	}
+	return nil
+}
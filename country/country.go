@@ -0,0 +1,337 @@
+// Package country normalizes country names and codes to ISO 3166-1 alpha-2 codes and classifies shipping routes into
+// domestic, EU, EU special territory and non-EU zones.
+package country
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrUnknown is returned for names and codes which do not belong to a country
+var ErrUnknown = errors.New("unknown country")
+
+// Country is an ISO 3166-1 country
+type Country struct {
+	Alpha2 string
+	Alpha3 string
+	Name   string
+}
+
+// countries contains all officially assigned ISO 3166-1 countries
+var countries = []Country{
+	{"AF", "AFG", "Afghanistan"}, {"AX", "ALA", "Åland Islands"}, {"AL", "ALB", "Albania"}, {"DZ", "DZA", "Algeria"},
+	{"AS", "ASM", "American Samoa"}, {"AD", "AND", "Andorra"}, {"AO", "AGO", "Angola"}, {"AI", "AIA", "Anguilla"},
+	{"AQ", "ATA", "Antarctica"}, {"AG", "ATG", "Antigua and Barbuda"}, {"AR", "ARG", "Argentina"},
+	{"AM", "ARM", "Armenia"}, {"AW", "ABW", "Aruba"}, {"AU", "AUS", "Australia"}, {"AT", "AUT", "Austria"},
+	{"AZ", "AZE", "Azerbaijan"}, {"BS", "BHS", "Bahamas"}, {"BH", "BHR", "Bahrain"}, {"BD", "BGD", "Bangladesh"},
+	{"BB", "BRB", "Barbados"}, {"BY", "BLR", "Belarus"}, {"BE", "BEL", "Belgium"}, {"BZ", "BLZ", "Belize"},
+	{"BJ", "BEN", "Benin"}, {"BM", "BMU", "Bermuda"}, {"BT", "BTN", "Bhutan"}, {"BO", "BOL", "Bolivia"},
+	{"BQ", "BES", "Bonaire, Sint Eustatius and Saba"}, {"BA", "BIH", "Bosnia and Herzegovina"},
+	{"BW", "BWA", "Botswana"}, {"BV", "BVT", "Bouvet Island"}, {"BR", "BRA", "Brazil"},
+	{"IO", "IOT", "British Indian Ocean Territory"}, {"BN", "BRN", "Brunei Darussalam"}, {"BG", "BGR", "Bulgaria"},
+	{"BF", "BFA", "Burkina Faso"}, {"BI", "BDI", "Burundi"}, {"CV", "CPV", "Cabo Verde"}, {"KH", "KHM", "Cambodia"},
+	{"CM", "CMR", "Cameroon"}, {"CA", "CAN", "Canada"}, {"KY", "CYM", "Cayman Islands"},
+	{"CF", "CAF", "Central African Republic"}, {"TD", "TCD", "Chad"}, {"CL", "CHL", "Chile"}, {"CN", "CHN", "China"},
+	{"CX", "CXR", "Christmas Island"}, {"CC", "CCK", "Cocos (Keeling) Islands"}, {"CO", "COL", "Colombia"},
+	{"KM", "COM", "Comoros"}, {"CG", "COG", "Congo"}, {"CD", "COD", "Congo, Democratic Republic of the"},
+	{"CK", "COK", "Cook Islands"}, {"CR", "CRI", "Costa Rica"}, {"CI", "CIV", "Côte d'Ivoire"},
+	{"HR", "HRV", "Croatia"}, {"CU", "CUB", "Cuba"}, {"CW", "CUW", "Curaçao"}, {"CY", "CYP", "Cyprus"},
+	{"CZ", "CZE", "Czechia"}, {"DK", "DNK", "Denmark"}, {"DJ", "DJI", "Djibouti"}, {"DM", "DMA", "Dominica"},
+	{"DO", "DOM", "Dominican Republic"}, {"EC", "ECU", "Ecuador"}, {"EG", "EGY", "Egypt"},
+	{"SV", "SLV", "El Salvador"}, {"GQ", "GNQ", "Equatorial Guinea"}, {"ER", "ERI", "Eritrea"},
+	{"EE", "EST", "Estonia"}, {"SZ", "SWZ", "Eswatini"}, {"ET", "ETH", "Ethiopia"},
+	{"FK", "FLK", "Falkland Islands"}, {"FO", "FRO", "Faroe Islands"}, {"FJ", "FJI", "Fiji"},
+	{"FI", "FIN", "Finland"}, {"FR", "FRA", "France"}, {"GF", "GUF", "French Guiana"},
+	{"PF", "PYF", "French Polynesia"}, {"TF", "ATF", "French Southern Territories"}, {"GA", "GAB", "Gabon"},
+	{"GM", "GMB", "Gambia"}, {"GE", "GEO", "Georgia"}, {"DE", "DEU", "Germany"}, {"GH", "GHA", "Ghana"},
+	{"GI", "GIB", "Gibraltar"}, {"GR", "GRC", "Greece"}, {"GL", "GRL", "Greenland"}, {"GD", "GRD", "Grenada"},
+	{"GP", "GLP", "Guadeloupe"}, {"GU", "GUM", "Guam"}, {"GT", "GTM", "Guatemala"}, {"GG", "GGY", "Guernsey"},
+	{"GN", "GIN", "Guinea"}, {"GW", "GNB", "Guinea-Bissau"}, {"GY", "GUY", "Guyana"}, {"HT", "HTI", "Haiti"},
+	{"HM", "HMD", "Heard Island and McDonald Islands"}, {"VA", "VAT", "Holy See"}, {"HN", "HND", "Honduras"},
+	{"HK", "HKG", "Hong Kong"}, {"HU", "HUN", "Hungary"}, {"IS", "ISL", "Iceland"}, {"IN", "IND", "India"},
+	{"ID", "IDN", "Indonesia"}, {"IR", "IRN", "Iran"}, {"IQ", "IRQ", "Iraq"}, {"IE", "IRL", "Ireland"},
+	{"IM", "IMN", "Isle of Man"}, {"IL", "ISR", "Israel"}, {"IT", "ITA", "Italy"}, {"JM", "JAM", "Jamaica"},
+	{"JP", "JPN", "Japan"}, {"JE", "JEY", "Jersey"}, {"JO", "JOR", "Jordan"}, {"KZ", "KAZ", "Kazakhstan"},
+	{"KE", "KEN", "Kenya"}, {"KI", "KIR", "Kiribati"}, {"KP", "PRK", "North Korea"}, {"KR", "KOR", "South Korea"},
+	{"KW", "KWT", "Kuwait"}, {"KG", "KGZ", "Kyrgyzstan"}, {"LA", "LAO", "Laos"}, {"LV", "LVA", "Latvia"},
+	{"LB", "LBN", "Lebanon"}, {"LS", "LSO", "Lesotho"}, {"LR", "LBR", "Liberia"}, {"LY", "LBY", "Libya"},
+	{"LI", "LIE", "Liechtenstein"}, {"LT", "LTU", "Lithuania"}, {"LU", "LUX", "Luxembourg"}, {"MO", "MAC", "Macao"},
+	{"MG", "MDG", "Madagascar"}, {"MW", "MWI", "Malawi"}, {"MY", "MYS", "Malaysia"}, {"MV", "MDV", "Maldives"},
+	{"ML", "MLI", "Mali"}, {"MT", "MLT", "Malta"}, {"MH", "MHL", "Marshall Islands"}, {"MQ", "MTQ", "Martinique"},
+	{"MR", "MRT", "Mauritania"}, {"MU", "MUS", "Mauritius"}, {"YT", "MYT", "Mayotte"}, {"MX", "MEX", "Mexico"},
+	{"FM", "FSM", "Micronesia"}, {"MD", "MDA", "Moldova"}, {"MC", "MCO", "Monaco"}, {"MN", "MNG", "Mongolia"},
+	{"ME", "MNE", "Montenegro"}, {"MS", "MSR", "Montserrat"}, {"MA", "MAR", "Morocco"}, {"MZ", "MOZ", "Mozambique"},
+	{"MM", "MMR", "Myanmar"}, {"NA", "NAM", "Namibia"}, {"NR", "NRU", "Nauru"}, {"NP", "NPL", "Nepal"},
+	{"NL", "NLD", "Netherlands"}, {"NC", "NCL", "New Caledonia"}, {"NZ", "NZL", "New Zealand"},
+	{"NI", "NIC", "Nicaragua"}, {"NE", "NER", "Niger"}, {"NG", "NGA", "Nigeria"}, {"NU", "NIU", "Niue"},
+	{"NF", "NFK", "Norfolk Island"}, {"MK", "MKD", "North Macedonia"}, {"MP", "MNP", "Northern Mariana Islands"},
+	{"NO", "NOR", "Norway"}, {"OM", "OMN", "Oman"}, {"PK", "PAK", "Pakistan"}, {"PW", "PLW", "Palau"},
+	{"PS", "PSE", "Palestine"}, {"PA", "PAN", "Panama"}, {"PG", "PNG", "Papua New Guinea"}, {"PY", "PRY", "Paraguay"},
+	{"PE", "PER", "Peru"}, {"PH", "PHL", "Philippines"}, {"PN", "PCN", "Pitcairn"}, {"PL", "POL", "Poland"},
+	{"PT", "PRT", "Portugal"}, {"PR", "PRI", "Puerto Rico"}, {"QA", "QAT", "Qatar"}, {"RE", "REU", "Réunion"},
+	{"RO", "ROU", "Romania"}, {"RU", "RUS", "Russian Federation"}, {"RW", "RWA", "Rwanda"},
+	{"BL", "BLM", "Saint Barthélemy"}, {"SH", "SHN", "Saint Helena, Ascension and Tristan da Cunha"},
+	{"KN", "KNA", "Saint Kitts and Nevis"}, {"LC", "LCA", "Saint Lucia"}, {"MF", "MAF", "Saint Martin (French part)"},
+	{"PM", "SPM", "Saint Pierre and Miquelon"}, {"VC", "VCT", "Saint Vincent and the Grenadines"},
+	{"WS", "WSM", "Samoa"}, {"SM", "SMR", "San Marino"}, {"ST", "STP", "Sao Tome and Principe"},
+	{"SA", "SAU", "Saudi Arabia"}, {"SN", "SEN", "Senegal"}, {"RS", "SRB", "Serbia"}, {"SC", "SYC", "Seychelles"},
+	{"SL", "SLE", "Sierra Leone"}, {"SG", "SGP", "Singapore"}, {"SX", "SXM", "Sint Maarten (Dutch part)"},
+	{"SK", "SVK", "Slovakia"}, {"SI", "SVN", "Slovenia"}, {"SB", "SLB", "Solomon Islands"}, {"SO", "SOM", "Somalia"},
+	{"ZA", "ZAF", "South Africa"}, {"GS", "SGS", "South Georgia and the South Sandwich Islands"},
+	{"SS", "SSD", "South Sudan"}, {"ES", "ESP", "Spain"}, {"LK", "LKA", "Sri Lanka"}, {"SD", "SDN", "Sudan"},
+	{"SR", "SUR", "Suriname"}, {"SJ", "SJM", "Svalbard and Jan Mayen"}, {"SE", "SWE", "Sweden"},
+	{"CH", "CHE", "Switzerland"}, {"SY", "SYR", "Syria"}, {"TW", "TWN", "Taiwan"}, {"TJ", "TJK", "Tajikistan"},
+	{"TZ", "TZA", "Tanzania"}, {"TH", "THA", "Thailand"}, {"TL", "TLS", "Timor-Leste"}, {"TG", "TGO", "Togo"},
+	{"TK", "TKL", "Tokelau"}, {"TO", "TON", "Tonga"}, {"TT", "TTO", "Trinidad and Tobago"}, {"TN", "TUN", "Tunisia"},
+	{"TR", "TUR", "Türkiye"}, {"TM", "TKM", "Turkmenistan"}, {"TC", "TCA", "Turks and Caicos Islands"},
+	{"TV", "TUV", "Tuvalu"}, {"UG", "UGA", "Uganda"}, {"UA", "UKR", "Ukraine"}, {"AE", "ARE", "United Arab Emirates"},
+	{"GB", "GBR", "United Kingdom"}, {"US", "USA", "United States"},
+	{"UM", "UMI", "United States Minor Outlying Islands"}, {"UY", "URY", "Uruguay"}, {"UZ", "UZB", "Uzbekistan"},
+	{"VU", "VUT", "Vanuatu"}, {"VE", "VEN", "Venezuela"}, {"VN", "VNM", "Viet Nam"},
+	{"VG", "VGB", "Virgin Islands (British)"}, {"VI", "VIR", "Virgin Islands (U.S.)"},
+	{"WF", "WLF", "Wallis and Futuna"}, {"EH", "ESH", "Western Sahara"}, {"YE", "YEM", "Yemen"},
+	{"ZM", "ZMB", "Zambia"}, {"ZW", "ZWE", "Zimbabwe"},
+}
+
+// aliases maps common, German and local country names to alpha-2 codes. Northern Ireland and its customs code XI
+// belong to GB, see customsTerritory.
+var aliases = map[string]string{
+	"deutschland": "DE", "bundesrepublik deutschland": "DE", "brd": "DE",
+	"osterreich": "AT", "oesterreich": "AT",
+	"schweiz": "CH", "suisse": "CH", "svizzera": "CH",
+	"frankreich": "FR",
+	"italien":    "IT", "italia": "IT",
+	"spanien": "ES", "espana": "ES",
+	"niederlande": "NL", "nederland": "NL", "holland": "NL", "the netherlands": "NL",
+	"belgien": "BE", "belgique": "BE", "belgie": "BE",
+	"luxemburg": "LU",
+	"danemark":  "DK", "daenemark": "DK", "danmark": "DK",
+	"schweden": "SE", "sverige": "SE",
+	"norwegen": "NO", "norge": "NO",
+	"finnland": "FI", "suomi": "FI",
+	"polen": "PL", "polska": "PL",
+	"tschechien": "CZ", "czech republic": "CZ", "tschechische republik": "CZ", "cesko": "CZ",
+	"slowakei": "SK", "slovensko": "SK",
+	"ungarn": "HU", "magyarorszag": "HU",
+	"slowenien": "SI", "slovenija": "SI",
+	"kroatien": "HR", "hrvatska": "HR",
+	"rumanien": "RO", "rumaenien": "RO", "romania": "RO",
+	"bulgarien":    "BG",
+	"griechenland": "GR", "hellas": "GR",
+	"irland": "IE", "eire": "IE",
+	"estland": "EE", "eesti": "EE",
+	"lettland": "LV", "latvija": "LV",
+	"litauen": "LT", "lietuva": "LT",
+	"zypern":                 "CY",
+	"vereinigtes konigreich": "GB", "vereinigtes koenigreich": "GB", "grossbritannien": "GB", "great britain": "GB",
+	"uk": "GB", "england": "GB", "scotland": "GB", "wales": "GB", "northern ireland": "GB", "nordirland": "GB", "xi": "GB",
+	"vereinigte staaten": "US", "usa": "US", "united states of america": "US", "america": "US",
+	"kanada": "CA", "australien": "AU", "neuseeland": "NZ",
+	"russland": "RU", "russia": "RU", "weissrussland": "BY", "belarus": "BY",
+	"turkei": "TR", "tuerkei": "TR", "turkey": "TR",
+	"vatikanstadt": "VA", "vatican city": "VA", "vatican": "VA",
+	"sudkorea": "KR", "korea": "KR", "republic of korea": "KR", "nordkorea": "KP",
+	"vietnam": "VN", "ivory coast": "CI", "cape verde": "CV", "swaziland": "SZ", "burma": "MM",
+	"brunei": "BN", "macedonia": "MK", "nordmazedonien": "MK", "china": "CN", "volksrepublik china": "CN",
+}
+
+// index maps alpha-2 and alpha-3 codes and folded names to countries
+var index = func() map[string]*Country {
+	m := make(map[string]*Country, len(countries)*3+len(aliases))
+	for i := range countries {
+		c := &countries[i]
+		m[c.Alpha2] = c
+		m[c.Alpha3] = c
+		m[fold(c.Name)] = c
+	}
+
+	for alias, code := range aliases {
+		m[alias] = m[code]
+	}
+	return m
+}()
+
+// fold lowercases s and removes diacritics and punctuation (e.g. "Côte d'Ivoire" -> "cote divoire")
+func fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, strings.ReplaceAll(s, "ß", "ss"))
+	if err != nil {
+		folded = s
+	}
+
+	folded = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == '\'' || r == '’':
+			return -1
+		}
+		return ' '
+	}, folded)
+	return strings.Join(strings.Fields(folded), " ")
+}
+
+// All returns all officially assigned ISO 3166-1 countries
+func All() []Country {
+	return append([]Country(nil), countries...)
+}
+
+// IsCode returns whether code is an ISO 3166-1 alpha-2 code (e.g. DE)
+func IsCode(code string) bool {
+	c, ok := index[code]
+	return ok && c.Alpha2 == code
+}
+
+// Lookup returns the country of an alpha-2 or alpha-3 code or a name (e.g. DE, DEU, Germany or Deutschland)
+func Lookup(s string) (Country, bool) {
+	s = strings.TrimSpace(s)
+	if c, ok := index[strings.ToUpper(s)]; ok && len(s) <= 3 {
+		return *c, true
+	}
+
+	if c, ok := index[fold(s)]; ok {
+		return *c, true
+	}
+	return Country{}, false
+}
+
+// Normalize returns the alpha-2 code of an alpha-2 or alpha-3 code or a country name
+func Normalize(s string) (string, error) {
+	c, ok := Lookup(s)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknown, s)
+	}
+	return c.Alpha2, nil
+}
+
+// euCountries are the member states of the European Union
+var euCountries = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "CY": true, "CZ": true, "DE": true, "DK": true, "EE": true, "ES": true,
+	"FI": true, "FR": true, "GR": true, "HR": true, "HU": true, "IE": true, "IT": true, "LT": true, "LU": true,
+	"LV": true, "MT": true, "NL": true, "PL": true, "PT": true, "RO": true, "SE": true, "SI": true, "SK": true,
+}
+
+// IsEU returns whether the alpha-2 code belongs to a member state of the European Union
+func IsEU(code string) bool {
+	return euCountries[code]
+}
+
+// Location is the country and the postal code of an address
+type Location struct {
+	Country    string // Alpha-2 or alpha-3 code or name
+	PostalCode string
+}
+
+// specialTerritory is a territory of an EU member state outside of the EU customs or VAT territory
+type specialTerritory struct {
+	name       string
+	country    string
+	postalCode *regexp.Regexp // nil if the territory has its own country code
+}
+
+var specialTerritories = []*specialTerritory{
+	{"Heligoland", "DE", regexp.MustCompile(`^27498$`)},
+	{"Büsingen am Hochrhein", "DE", regexp.MustCompile(`^78266$`)},
+	{"Canary Islands", "ES", regexp.MustCompile(`^3[58]\d{3}$`)},
+	{"Ceuta", "ES", regexp.MustCompile(`^51\d{3}$`)},
+	{"Melilla", "ES", regexp.MustCompile(`^52\d{3}$`)},
+	{"Livigno", "IT", regexp.MustCompile(`^23041$`)},
+	{"Mount Athos", "GR", regexp.MustCompile(`^630 ?8[67]$`)},
+	{"Åland Islands", "FI", regexp.MustCompile(`^22\d{3}$`)},
+	{"Åland Islands", "AX", nil},
+	{"French overseas departments", "FR", regexp.MustCompile(`^97[1-6]\d{2}$`)},
+	{"Guadeloupe", "GP", nil},
+	{"Martinique", "MQ", nil},
+	{"French Guiana", "GF", nil},
+	{"Réunion", "RE", nil},
+	{"Mayotte", "YT", nil},
+	{"Saint Martin", "MF", nil},
+}
+
+// SpecialTerritory returns the name of the EU special territory of the location (e.g. Heligoland or Canary Islands). An
+// empty string is returned if the location is not in a special territory.
+func SpecialTerritory(l Location) string {
+	code, err := Normalize(l.Country)
+	if err != nil {
+		return ""
+	}
+
+	pc := strings.TrimSpace(l.PostalCode)
+	for _, t := range specialTerritories {
+		if t.country == code && (t.postalCode == nil || t.postalCode.MatchString(pc)) {
+			return t.name
+		}
+	}
+	return ""
+}
+
+// northernIrelandPostalCode matches the BT postcodes of Northern Ireland
+var northernIrelandPostalCode = regexp.MustCompile(`^BT\d`)
+
+// customsTerritory returns the customs territory of a location: XI for Northern Ireland, which stays in the EU customs
+// territory for goods under the Windsor Framework, otherwise the alpha-2 code
+func customsTerritory(l Location, code string) string {
+	if code != "GB" {
+		return code
+	}
+
+	s := fold(l.Country)
+	pc := strings.ToUpper(strings.TrimSpace(l.PostalCode))
+	if s == "xi" || s == "northern ireland" || s == "nordirland" || northernIrelandPostalCode.MatchString(pc) {
+		return "XI"
+	}
+	return code
+}
+
+// isEUCustomsTerritory returns whether the customs territory belongs to the EU customs and VAT territory for goods.
+// Monaco belongs to the French territory and Northern Ireland (XI) follows the EU rules for goods.
+func isEUCustomsTerritory(code string) bool {
+	return IsEU(code) || code == "MC" || code == "XI"
+}
+
+type Zone string
+
+const (
+	ZoneDomestic           Zone = "DOMESTIC"
+	ZoneEU                 Zone = "EU"
+	ZoneEUSpecialTerritory Zone = "EU_SPECIAL_TERRITORY"
+	ZoneNonEU              Zone = "NON_EU"
+)
+
+// RequiresCustoms returns whether shipments of the zone need customs documents
+func (z Zone) RequiresCustoms() bool {
+	return z == ZoneEUSpecialTerritory || z == ZoneNonEU
+}
+
+// Classify returns the zone of a route. Routes from or to an EU special territory are ZoneEUSpecialTerritory, even
+// within the same member state. Routes between Northern Ireland (a GB address with a BT postcode or the code XI) and
+// the EU are ZoneEU, routes between Northern Ireland and Great Britain are ZoneDomestic.
+func Classify(from, to Location) (Zone, error) {
+	fromCode, err := Normalize(from.Country)
+	if err != nil {
+		return "", err
+	}
+
+	toCode, err := Normalize(to.Country)
+	if err != nil {
+		return "", err
+	}
+
+	fromTerritory, toTerritory := SpecialTerritory(from), SpecialTerritory(to)
+	switch {
+	case fromCode == toCode && fromTerritory == toTerritory:
+		return ZoneDomestic, nil
+	case fromTerritory != "" || toTerritory != "":
+		return ZoneEUSpecialTerritory, nil
+	case isEUCustomsTerritory(customsTerritory(from, fromCode)) && isEUCustomsTerritory(customsTerritory(to, toCode)):
+		return ZoneEU, nil
+	}
+	return ZoneNonEU, nil
+}
@@ -0,0 +1,101 @@
+package country
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	if n := len(All()); n != 249 {
+		t.Errorf("expected 249 countries, got %d", n)
+	}
+
+	for alias, code := range aliases {
+		if index[alias] == nil {
+			t.Errorf("alias %q: unknown country %s", alias, code)
+		}
+	}
+
+	tests := map[string]string{
+		"DE": "DE", "de": "DE", "DEU": "DE", "Germany": "DE", "Deutschland": "DE", " germany ": "DE",
+		"Österreich": "AT", "Oesterreich": "AT", "AUT": "AT", "Côte d'Ivoire": "CI", "Cote dIvoire": "CI",
+		"UK": "GB", "Great Britain": "GB", "USA": "US", "United States of America": "US", "Türkei": "TR",
+		"Northern Ireland": "GB", "XI": "GB",
+	}
+	for s, code := range tests {
+		got, err := Normalize(s)
+		if err != nil || got != code {
+			t.Errorf("Normalize(%q) = %s, %v, expected %s", s, got, err, code)
+		}
+	}
+
+	if _, err := Normalize("Atlantis"); !errors.Is(err, ErrUnknown) {
+		t.Errorf("expected unknown country, got %v", err)
+	}
+
+	for code, expected := range map[string]bool{"DE": true, "DEU": false, "XK": false, "XI": false} {
+		if IsCode(code) != expected {
+			t.Errorf("IsCode(%q): expected %t", code, expected)
+		}
+	}
+
+	// Callers cannot change the countries
+	all := All()
+	all[0].Alpha2 = "XX"
+	if c, _ := Lookup("AFG"); c.Alpha2 != "AF" {
+		t.Errorf("expected AF, got %s", c.Alpha2)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	berlin := Location{Country: "DE", PostalCode: "10115"}
+	belfast := Location{Country: "GB", PostalCode: "BT1 5GS"}
+	tests := []struct {
+		from, to Location
+		zone     Zone
+	}{
+		{berlin, Location{Country: "Deutschland", PostalCode: "80331"}, ZoneDomestic},
+		{berlin, Location{Country: "DE", PostalCode: "27498"}, ZoneEUSpecialTerritory},
+		{berlin, Location{Country: "DE", PostalCode: "78266"}, ZoneEUSpecialTerritory},
+		{berlin, Location{Country: "FRA", PostalCode: "75001"}, ZoneEU},
+		{berlin, Location{Country: "MC", PostalCode: "98000"}, ZoneEU},
+		{berlin, Location{Country: "ES", PostalCode: "35001"}, ZoneEUSpecialTerritory},
+		{berlin, Location{Country: "ES", PostalCode: "28001"}, ZoneEU},
+		{berlin, Location{Country: "CH", PostalCode: "8001"}, ZoneNonEU},
+		{berlin, Location{Country: "United Kingdom", PostalCode: "NW1 6XE"}, ZoneNonEU},
+
+		// Campione d'Italia belongs to the EU customs territory since 2020
+		{berlin, Location{Country: "IT", PostalCode: "22061"}, ZoneEU},
+
+		// Northern Ireland follows the EU rules for goods
+		{berlin, belfast, ZoneEU},
+		{berlin, Location{Country: "GB", PostalCode: "bt9 7bl"}, ZoneEU},
+		{berlin, Location{Country: "Northern Ireland", PostalCode: "BT1 5GS"}, ZoneEU},
+		{berlin, Location{Country: "XI"}, ZoneEU},
+		{belfast, Location{Country: "IE", PostalCode: "D02 X285"}, ZoneEU},
+		{belfast, Location{Country: "GB", PostalCode: "NW1 6XE"}, ZoneDomestic},
+		{Location{Country: "CH", PostalCode: "8001"}, belfast, ZoneNonEU},
+
+		// Within the Canary Islands
+		{Location{Country: "ES", PostalCode: "35001"}, Location{Country: "ES", PostalCode: "38001"}, ZoneDomestic},
+	}
+
+	for _, tt := range tests {
+		zone, err := Classify(tt.from, tt.to)
+		if err != nil || zone != tt.zone {
+			t.Errorf("%+v -> %+v: expected %s, got %s, %v", tt.from, tt.to, tt.zone, zone, err)
+		}
+
+		if customs := tt.zone == ZoneEUSpecialTerritory || tt.zone == ZoneNonEU; zone.RequiresCustoms() != customs {
+			t.Errorf("%+v -> %+v: expected customs %t", tt.from, tt.to, customs)
+		}
+	}
+
+	if name := SpecialTerritory(Location{Country: "DE", PostalCode: "27498"}); name != "Heligoland" {
+		t.Errorf("expected Heligoland, got %q", name)
+	}
+
+	if _, err := Classify(berlin, Location{Country: "Atlantis"}); !errors.Is(err, ErrUnknown) {
+		t.Errorf("expected unknown country, got %v", err)
+	}
+}
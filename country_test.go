@@ -0,0 +1,51 @@
+package shippinglabel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dewaco/shippinglabel/country"
+)
+
+func TestClassifyRoute(t *testing.T) {
+	berlin := &Address{Country: "DE", PostalCode: "10115"}
+	tests := []struct {
+		receiver *Address
+		zone     country.Zone
+		customs  bool
+	}{
+		{&Address{Country: "Deutschland", PostalCode: "80331"}, country.ZoneDomestic, false},
+		{&Address{Country: "DE", PostalCode: "27498"}, country.ZoneEUSpecialTerritory, true},
+		{&Address{Country: "FRA", PostalCode: "75001"}, country.ZoneEU, false},
+		{&Address{Country: "CH", PostalCode: "8001"}, country.ZoneNonEU, true},
+		{&Address{Country: "GB", PostalCode: "BT1 5GS"}, country.ZoneEU, false},
+	}
+
+	for _, tt := range tests {
+		zone, err := ClassifyRoute(berlin, tt.receiver)
+		isNoError(t, err)
+		if zone != tt.zone {
+			t.Errorf("%+v: expected %s, got %s", tt.receiver, tt.zone, zone)
+		}
+
+		customs, err := RequiresCustoms(berlin, tt.receiver)
+		isNoError(t, err)
+		isEqual(t, customs, tt.customs)
+	}
+
+	if _, err := ClassifyRoute(berlin, nil); !errors.Is(err, ErrRequiredAddress) {
+		t.Errorf("expected required address, got %v", err)
+	}
+
+	if _, err := NormalizeCountry("Atlantis"); !errors.Is(err, country.ErrUnknown) {
+		t.Errorf("expected unknown country, got %v", err)
+	}
+
+	s := &Shipment{Sender: berlin, Receiver: &Address{Country: "CH", PostalCode: "8001"}}
+	if err := CheckCustoms(s); !errors.Is(err, ErrRequiredCustoms) {
+		t.Errorf("expected missing customs, got %v", err)
+	}
+
+	s.Customs = &Customs{Items: []*CustomsItem{{Description: "Book", Quantity: 1}}}
+	isNoError(t, CheckCustoms(s))
+}
@@ -1,6 +1,11 @@
 package shippinglabel
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type EncodingCode string
 
@@ -25,3 +30,81 @@ type Mapping struct {
 	HeaderField string `json:"headerField,omitempty"`
 	ValueID     string `json:"valueId,omitempty"`
 }
+
+// CSVProfileError contains all problems of an invalid CSVProfile
+type CSVProfileError struct {
+	Problems []string
+}
+
+func (m *CSVProfileError) Error() string {
+	return "invalid csv profile: " + strings.Join(m.Problems, "; ")
+}
+
+// Validate checks the delimiter, the encoding and the mapping of the profile. Unknown ValueIDs are reported with the
+// closest ValueID of the catalogue. CreateCSVProfile and UpdateCSVProfile do not call it, because the server may
+// support ValueIDs which are not part of the catalogue. Use CreateValidatedCSVProfile and UpdateValidatedCSVProfile
+// to validate the profile before it is sent.
+func (m *CSVProfile) Validate() error {
+	problems := make([]string, 0)
+	if _, err := csvDelimiter(m); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if _, err := csvEncoding(m.Encoding); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(m.Mapping) == 0 {
+		problems = append(problems, "mapping is required")
+	}
+
+	headers := make(map[string]bool, len(m.Mapping))
+	for i, mp := range m.Mapping {
+		if mp == nil {
+			problems = append(problems, fmt.Sprintf("mapping %d is empty", i))
+			continue
+		}
+
+		if strings.TrimSpace(mp.HeaderField) == "" {
+			problems = append(problems, fmt.Sprintf("mapping %d: header field is required", i))
+		} else if headers[mp.HeaderField] {
+			problems = append(problems, fmt.Sprintf("duplicate header field %q", mp.HeaderField))
+		}
+		headers[mp.HeaderField] = true
+
+		if !IsValidValueID(mp.ValueID) {
+			problems = append(problems, fmt.Sprintf("unknown value id %q (did you mean %q?)", mp.ValueID, SuggestValueID(mp.ValueID)))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &CSVProfileError{Problems: problems}
+	}
+	return nil
+}
+
+// CreateValidatedCSVProfile validates the profile and creates it with CreateCSVProfile. An invalid profile is rejected
+// with a *CSVProfileError and is not sent.
+func (c *APIContext) CreateValidatedCSVProfile(ctx context.Context, v *CSVProfile) (*CSVProfile, error) {
+	if v == nil {
+		return nil, ErrRequiredCSVProfile
+	}
+
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return c.CreateCSVProfile(ctx, v)
+}
+
+// UpdateValidatedCSVProfile validates the profile and updates it with UpdateCSVProfile. An invalid profile is rejected
+// with a *CSVProfileError and is not sent.
+func (c *APIContext) UpdateValidatedCSVProfile(ctx context.Context, v *CSVProfile) error {
+	if v == nil {
+		return ErrRequiredCSVProfile
+	}
+
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	return c.UpdateCSVProfile(ctx, v)
+}
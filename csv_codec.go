@@ -0,0 +1,276 @@
+package shippinglabel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// CSVError is a CSV decoding error with the line and the header field
+type CSVError struct {
+	Line        int
+	HeaderField string
+	Err         error
+}
+
+func (m *CSVError) Error() string {
+	if m.HeaderField == "" {
+		return fmt.Sprintf("csv line %d: %v", m.Line, m.Err)
+	}
+	return fmt.Sprintf("csv line %d, field %q: %v", m.Line, m.HeaderField, m.Err)
+}
+
+func (m *CSVError) Unwrap() error {
+	return m.Err
+}
+
+// MarshalCSV converts the shipments into a CSV file which follows the CSVProfile
+func MarshalCSV(profile *CSVProfile, shipments []*Shipment) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := EncodeCSV(buf, profile, shipments); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeCSV writes the shipments as CSV in the delimiter, encoding and date format of the CSVProfile. The header row
+// contains the HeaderFields of the profile mapping. The profile is validated first.
+func EncodeCSV(w io.Writer, profile *CSVProfile, shipments []*Shipment) error {
+	if profile == nil {
+		return ErrRequiredCSVProfile
+	}
+
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	comma, err := csvDelimiter(profile)
+	if err != nil {
+		return err
+	}
+
+	enc, err := csvEncoding(profile.Encoding)
+	if err != nil {
+		return err
+	}
+
+	// Values which cannot be encoded (e.g. "€" in ISO-8859-1) are reported with their line and header field
+	check := func(line int, field string, v string) error {
+		if enc == unicode.UTF8 || enc == unicode.UTF8BOM {
+			return nil
+		}
+
+		if _, err := enc.NewEncoder().String(v); err != nil {
+			return &CSVError{Line: line, HeaderField: field, Err: fmt.Errorf("%w: value cannot be encoded as %s", ErrUnsupportedEncoding, profile.Encoding)}
+		}
+		return nil
+	}
+
+	tw := transform.NewWriter(w, enc.NewEncoder())
+	cw := csv.NewWriter(tw)
+	cw.Comma = comma
+
+	header := make([]string, 0, len(profile.Mapping))
+	for _, m := range profile.Mapping {
+		if err = check(1, m.HeaderField, m.HeaderField); err != nil {
+			return err
+		}
+		header = append(header, m.HeaderField)
+	}
+
+	if err = cw.Write(header); err != nil {
+		return err
+	}
+
+	f := valueFormat{dateLayout: DateLayoutFromFormat(profile.DateFormat)}
+	for i, s := range shipments {
+		row := make([]string, len(profile.Mapping))
+		for j, m := range profile.Mapping {
+			v, ok, err := getValueID(reflect.ValueOf(s), m.ValueID)
+			if err != nil {
+				return &CSVError{Line: i + 2, HeaderField: m.HeaderField, Err: err}
+			}
+
+			if !ok {
+				continue
+			}
+
+			if row[j], err = formatValue(v, f); err != nil {
+				return &CSVError{Line: i + 2, HeaderField: m.HeaderField, Err: err}
+			}
+
+			if err = check(i+2, m.HeaderField, row[j]); err != nil {
+				return err
+			}
+		}
+
+		if err = cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	if err = cw.Error(); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// UnmarshalCSV parses a CSV file which follows the CSVProfile into shipments
+func UnmarshalCSV(b []byte, profile *CSVProfile) ([]*Shipment, error) {
+	return DecodeCSV(bytes.NewReader(b), profile)
+}
+
+// DecodeCSV parses a CSV file which follows the CSVProfile into shipments. Columns without a mapping are ignored, but
+// all header fields of the mapping must exist. An invalid profile is rejected with a *CSVProfileError.
+func DecodeCSV(r io.Reader, profile *CSVProfile) ([]*Shipment, error) {
+	if profile == nil {
+		return nil, ErrRequiredCSVProfile
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	comma, err := csvDelimiter(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := csvEncoding(profile.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	// A BOM is removed for every UTF-8 encoding
+	dec := enc.NewDecoder()
+	if enc == unicode.UTF8 {
+		dec = unicode.UTF8BOM.NewDecoder()
+	}
+
+	cr := csv.NewReader(transform.NewReader(r, dec))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &CSVError{Line: 1, Err: ErrMissingCSVHeader}
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.TrimSpace(h)] = i
+	}
+
+	idx := make([]int, len(profile.Mapping))
+	for i, m := range profile.Mapping {
+		if m == nil {
+			return nil, &CSVProfileError{Problems: []string{fmt.Sprintf("mapping %d is empty", i)}}
+		}
+
+		col, ok := columns[m.HeaderField]
+		if !ok {
+			return nil, &CSVError{Line: 1, HeaderField: m.HeaderField, Err: ErrMissingCSVHeader}
+		}
+		idx[i] = col
+	}
+
+	f := valueFormat{dateLayout: DateLayoutFromFormat(profile.DateFormat)}
+	shipments := make([]*Shipment, 0)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		if isEmptyRecord(record) {
+			continue
+		}
+
+		s := &Shipment{}
+		for i, m := range profile.Mapping {
+			if idx[i] >= len(record) {
+				continue
+			}
+
+			if err = setValueID(reflect.ValueOf(s).Elem(), m.ValueID, record[idx[i]], f); err != nil {
+				return nil, &CSVError{Line: line, HeaderField: m.HeaderField, Err: err}
+			}
+		}
+		shipments = append(shipments, s)
+	}
+	return shipments, nil
+}
+
+// DateLayoutFromFormat converts a CSVProfile date format (e.g. YYYY-MM-DD or DD.MM.YYYY HH:mm) into a Go time layout
+func DateLayoutFromFormat(format string) string {
+	if format == "" {
+		return DateLayout
+	}
+
+	r := strings.NewReplacer(
+		"YYYY", "2006", "yyyy", "2006",
+		"YY", "06", "yy", "06",
+		"MM", "01",
+		"DD", "02", "dd", "02",
+		"HH", "15", "hh", "15",
+		"mm", "04",
+		"SS", "05", "ss", "05",
+	)
+	return r.Replace(format)
+}
+
+func csvDelimiter(profile *CSVProfile) (rune, error) {
+	d := profile.Delimiter
+	switch d {
+	case "":
+		return ';', nil
+	case "\\t", "TAB", "tab":
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(d)
+	if size != len(d) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDelimiter, d)
+	}
+	return r, nil
+}
+
+func csvEncoding(code EncodingCode) (encoding.Encoding, error) {
+	switch code {
+	case "", EncodingUTF8:
+		return unicode.UTF8, nil
+	case EncodingUTF8BOM:
+		return unicode.UTF8BOM, nil
+	case EncodingISO88591:
+		return charmap.ISO8859_1, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, code)
+}
+
+func isEmptyRecord(record []string) bool {
+	for _, s := range record {
+		if strings.TrimSpace(s) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package shippinglabel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testCSVProfile(encoding EncodingCode) *CSVProfile {
+	return &CSVProfile{
+		Delimiter:  ";",
+		DateFormat: "DD.MM.YYYY",
+		Encoding:   encoding,
+		Mapping: []*Mapping{
+			{HeaderField: "Carrier", ValueID: "Carrier.Code"},
+			{HeaderField: "Name", ValueID: "Receiver.LastName"},
+			{HeaderField: "Strasse", ValueID: "Receiver.Street"},
+			{HeaderField: "PLZ", ValueID: "Receiver.PostalCode"},
+			{HeaderField: "Gewicht", ValueID: "Parcels.0.Weight"},
+			{HeaderField: "Datum", ValueID: "ShipmentDate"},
+			{HeaderField: "Order", ValueID: "AdditionalDetails.order"},
+		},
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	date := time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC)
+	shipments := []*Shipment{
+		{
+			Carrier:           &Carrier{Code: CarrierDHL},
+			Receiver:          &Address{LastName: "Müller", Street: "Hauptstraße", PostalCode: "10115"},
+			Parcels:           []*Parcel{{Weight: 1.5}},
+			ShipmentDate:      &date,
+			AdditionalDetails: map[string]any{"order": "A-1"},
+		},
+		{Carrier: &Carrier{Code: CarrierGLS}},
+	}
+
+	b, err := MarshalCSV(testCSVProfile(EncodingISO88591), shipments)
+	isNoError(t, err)
+
+	expected := "Carrier;Name;Strasse;PLZ;Gewicht;Datum;Order\nDHL;M\xfcller;Hauptstra\xdfe;10115;1.5;16.01.2023;A-1\nGLS;;;;;;\n"
+	isEqual(t, expected, string(b))
+
+	decoded, err := UnmarshalCSV(b, testCSVProfile(EncodingISO88591))
+	isNoError(t, err)
+	isEqual(t, 2, len(decoded))
+	isEqual(t, shipments[0], decoded[0])
+	isEqual(t, CarrierGLS, decoded[1].Carrier.Code)
+
+	b, err = MarshalCSV(testCSVProfile(EncodingUTF8BOM), shipments[:1])
+	isNoError(t, err)
+	if !bytes.HasPrefix(b, []byte("\xef\xbb\xbfCarrier;")) {
+		t.Fatalf("expected BOM: %q", b)
+	}
+
+	_, err = MarshalCSV(testCSVProfile(EncodingISO88591), []*Shipment{{Receiver: &Address{LastName: "Łukasz"}}})
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Fatalf("expected encoding error, got %v", err)
+	}
+	isEqual(t, 2, csvErr.Line)
+	isEqual(t, "Name", csvErr.HeaderField)
+
+	profile := testCSVProfile(EncodingUTF8)
+	profile.Mapping = append(profile.Mapping, nil)
+	var profileErr *CSVProfileError
+	if _, err = MarshalCSV(profile, shipments); !errors.As(err, &profileErr) {
+		t.Fatalf("expected profile error, got %v", err)
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	csvData := "PLZ;Ignored;Carrier;Name;Strasse;Gewicht;Datum;Order\n10115;x;DPD;Meier;Weg 1;2,25;01.02.2023;\n;;;;;;;\n"
+	shipments, err := DecodeCSV(bytes.NewReader([]byte(csvData)), testCSVProfile(EncodingUTF8))
+	isNoError(t, err)
+	isEqual(t, 1, len(shipments))
+	isEqual(t, 2.25, shipments[0].Parcels[0].Weight)
+	isEqual(t, CarrierDPD, shipments[0].Carrier.Code)
+
+	_, err = UnmarshalCSV([]byte("PLZ;Carrier;Name;Strasse;Gewicht;Datum;Order\n1;DHL;a;b;heavy;;\n"), testCSVProfile(EncodingUTF8))
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Line != 2 || csvErr.HeaderField != "Gewicht" {
+		t.Fatalf("expected csv error, got %v", err)
+	}
+
+	_, err = UnmarshalCSV([]byte("PLZ\n1\n"), testCSVProfile(EncodingUTF8))
+	if !errors.Is(err, ErrMissingCSVHeader) {
+		t.Fatalf("expected missing header, got %v", err)
+	}
+
+	profile := testCSVProfile(EncodingUTF8)
+	profile.Mapping = append(profile.Mapping, &Mapping{HeaderField: "Ignored", ValueID: "Receiver.ID"})
+	var profileErr *CSVProfileError
+	if _, err = UnmarshalCSV([]byte(csvData), profile); !errors.As(err, &profileErr) {
+		t.Fatalf("expected profile error, got %v", err)
+	}
+}
+
+func TestCSVProfile_Validate(t *testing.T) {
+	isNoError(t, testCSVProfile(EncodingUTF8).Validate())
+	isEqual(t, true, IsValidValueID("Parcels.2.Weight"))
+	isEqual(t, true, IsValidValueID("AdditionalDetails.order"))
+	isEqual(t, false, IsValidValueID("Receiver.ID"))
+	isEqual(t, true, IsValidValueID("Parcels.999.Weight"))
+	isEqual(t, false, IsValidValueID("Parcels.1000.Weight"))
+	isEqual(t, false, IsValidValueID("Parcels.-1.Weight"))
+	isEqual(t, true, IsValidValueID("AdditionalDetails.1000"))
+
+	// The catalogue cannot be changed by callers
+	ids := ValueIDs()
+	ids[0] = "changed"
+	isEqual(t, false, ValueIDs()[0] == "changed")
+
+	profile := testCSVProfile("UTF-16")
+	profile.Mapping = append(profile.Mapping, &Mapping{HeaderField: "PLZ", ValueID: "Reciever.PostalCod"})
+
+	var profileErr *CSVProfileError
+	if err := profile.Validate(); !errors.As(err, &profileErr) || len(profileErr.Problems) != 3 {
+		t.Fatalf("expected 3 problems, got %v", err)
+	}
+	isEqual(t, `unknown value id "Reciever.PostalCod" (did you mean "Receiver.PostalCode"?)`, profileErr.Problems[2])
+}
+
+func TestAPIContext_CreateValidatedCSVProfile(t *testing.T) {
+	var requests int
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method == http.MethodPost {
+			_ = json.NewEncoder(w).Encode(&CSVProfile{ID: 1})
+		}
+	}))
+
+	ctx := context.Background()
+	profile, err := api.CreateValidatedCSVProfile(ctx, testCSVProfile(EncodingUTF8))
+	isNoError(t, err)
+	isEqual(t, 1, profile.ID)
+
+	// Invalid profiles are not sent
+	invalid := testCSVProfile(EncodingUTF8)
+	invalid.Mapping[0].ValueID = "Carrier.Cod"
+	var profileErr *CSVProfileError
+	if _, err = api.CreateValidatedCSVProfile(ctx, invalid); !errors.As(err, &profileErr) {
+		t.Fatalf("expected profile error, got %v", err)
+	}
+
+	if err = api.UpdateValidatedCSVProfile(ctx, invalid); !errors.As(err, &profileErr) {
+		t.Fatalf("expected profile error, got %v", err)
+	}
+	isEqual(t, 1, requests)
+
+	isNoError(t, api.UpdateValidatedCSVProfile(ctx, testCSVProfile(EncodingUTF8)))
+	isEqual(t, 2, requests)
+}
@@ -0,0 +1,403 @@
+package shippinglabel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// maxInferSampleSize limits the bytes which are read by InferCSVProfile. A longer sample is cut after its last full
+// line, or after its last full UTF-8 character if it contains no line break.
+const maxInferSampleSize = 1 << 20
+
+// trimPartialRune removes an incomplete UTF-8 character at the end of b
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// InferredMapping is a proposed mapping with a confidence between 0 and 1
+type InferredMapping struct {
+	Mapping    *Mapping
+	Confidence float64
+}
+
+// InferredCSVProfile is the result of InferCSVProfile
+type InferredCSVProfile struct {
+	Profile  *CSVProfile        // Proposed profile with all inferred mappings
+	Mappings []*InferredMapping // Inferred mappings in the order of the header
+	Unmapped []string           // Header fields without a mapping
+}
+
+// InferCSVProfile sniffs the delimiter, encoding and date format of a CSV sample and proposes the mapping of the
+// header fields by matching them against German and English synonyms of the ValueIDs.
+func InferCSVProfile(sample io.Reader) (*InferredCSVProfile, error) {
+	b, err := io.ReadAll(io.LimitReader(sample, maxInferSampleSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) > maxInferSampleSize {
+		b = b[:maxInferSampleSize]
+		if i := bytes.LastIndexByte(b, '\n'); i >= 0 {
+			b = b[:i+1]
+		} else {
+			b = trimPartialRune(b)
+		}
+	}
+
+	profile := &CSVProfile{}
+	if b, profile.Encoding, err = decodeText(b); err != nil {
+		return nil, err
+	}
+
+	comma, records := sniffDelimiter(b)
+	if len(records) == 0 || len(records[0]) == 0 {
+		return nil, ErrMissingCSVHeader
+	}
+
+	profile.Delimiter = string(comma)
+	profile.DateFormat = sniffDateFormat(records[1:])
+
+	res := &InferredCSVProfile{Profile: profile}
+	res.Mappings, res.Unmapped = inferMappings(records[0])
+	for _, m := range res.Mappings {
+		profile.Mapping = append(profile.Mapping, m.Mapping)
+	}
+	return res, nil
+}
+
+// decodeText detects the encoding of a CSV or vCard file (UTF-8 with or without BOM or ISO-8859-1) and returns the
+// content as UTF-8
+func decodeText(b []byte) ([]byte, EncodingCode, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte("\xef\xbb\xbf")):
+		return b[3:], EncodingUTF8BOM, nil
+	case !utf8.Valid(b):
+		decoded, err := charmap.ISO8859_1.NewDecoder().Bytes(b)
+		return decoded, EncodingISO88591, err
+	}
+	return b, EncodingUTF8, nil
+}
+
+// sniffDelimiter returns the delimiter which splits the sample into the most consistent columns
+func sniffDelimiter(b []byte) (rune, [][]string) {
+	bestComma, bestScore := ';', -1
+	var bestRecords [][]string
+	for _, comma := range []rune{';', ',', '\t', '|'} {
+		cr := csv.NewReader(bytes.NewReader(b))
+		cr.Comma = comma
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+
+		records := make([][]string, 0)
+		for len(records) < 100 {
+			record, err := cr.Read()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					records = nil
+				}
+				break
+			}
+			records = append(records, record)
+		}
+
+		if len(records) == 0 {
+			continue
+		}
+
+		// Score: number of columns if all rows have the same number of columns
+		score := len(records[0])
+		for _, r := range records[1:] {
+			if len(r) != len(records[0]) && !isEmptyRecord(r) {
+				score = 0
+				break
+			}
+		}
+
+		if score > 1 && score > bestScore {
+			bestComma, bestScore, bestRecords = comma, score, records
+		}
+	}
+
+	if bestRecords == nil {
+		cr := csv.NewReader(bytes.NewReader(b))
+		cr.Comma = bestComma
+		cr.FieldsPerRecord = -1
+		bestRecords, _ = cr.ReadAll()
+	}
+	return bestComma, bestRecords
+}
+
+var dateFormatPatterns = []struct {
+	re     *regexp.Regexp
+	format func(m []string) string
+}{
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:[ T](\d{2}:\d{2})(:\d{2})?)?$`), func(m []string) string { return "YYYY-MM-DD" }},
+	{regexp.MustCompile(`^\d{2}\.\d{2}\.\d{4}(?: (\d{2}:\d{2})(:\d{2})?)?$`), func(m []string) string { return "DD.MM.YYYY" }},
+	{regexp.MustCompile(`^\d{2}\.\d{2}\.\d{2}$`), func(m []string) string { return "DD.MM.YY" }},
+	{regexp.MustCompile(`^(\d{2})/(\d{2})/\d{4}(?: (\d{2}:\d{2})(:\d{2})?)?$`), func(m []string) string {
+		// Day first unless the second number cannot be a month
+		if first, _ := strconv.Atoi(m[1]); first > 12 {
+			return "DD/MM/YYYY"
+		}
+		if second, _ := strconv.Atoi(m[2]); second > 12 {
+			return "MM/DD/YYYY"
+		}
+		return "DD/MM/YYYY"
+	}},
+}
+
+// sniffDateFormat returns the most frequent date format of the values in the records
+func sniffDateFormat(records [][]string) string {
+	counts := make(map[string]int)
+	for _, r := range records {
+		for _, v := range r {
+			v = strings.TrimSpace(v)
+			for _, p := range dateFormatPatterns {
+				m := p.re.FindStringSubmatch(v)
+				if m == nil {
+					continue
+				}
+
+				format := p.format(m)
+				if strings.ContainsAny(v, ":") {
+					// ISO 8601 separates the time with a T (e.g. 2023-01-16T10:30)
+					sep := " "
+					if strings.Contains(v, "T") {
+						sep = "T"
+					}
+					format += sep + "HH:mm"
+					if strings.Count(v, ":") == 2 {
+						format += ":ss"
+					}
+				}
+				counts[format]++
+				break
+			}
+		}
+	}
+
+	best, bestCount := "YYYY-MM-DD", 0
+	for format, n := range counts {
+		if n > bestCount || (n == bestCount && format < best) {
+			best, bestCount = format, n
+		}
+	}
+	return best
+}
+
+// addressFieldSynonyms contains the synonyms of the Address fields (normalized, without spaces)
+var addressFieldSynonyms = map[string][]string{
+	"Company":         {"firma", "company", "unternehmen", "firmenname", "companyname", "organisation", "organization"},
+	"FirstName":       {"vorname", "firstname", "givenname", "forename"},
+	"LastName":        {"nachname", "lastname", "surname", "familyname", "familienname", "name", "fullname"},
+	"Street":          {"strasse", "street", "streetname", "strassenname", "adresse", "address", "address1", "addressline1", "anschrift"},
+	"StreetNumber":    {"hausnummer", "hausnr", "hnr", "nr", "nummer", "housenumber", "streetnumber", "houseno", "number", "no"},
+	"PostalCode":      {"plz", "postleitzahl", "zip", "zipcode", "postcode", "postalcode"},
+	"AddressAddition": {"adresszusatz", "zusatz", "addressaddition", "address2", "addressline2", "co"},
+	"City":            {"ort", "stadt", "city", "town", "wohnort"},
+	"Country":         {"land", "country", "countrycode", "laenderkennzeichen", "lkz", "laendercode"},
+	"State":           {"bundesland", "state", "province", "region", "county"},
+	"Mail":            {"email", "mail", "emailadresse", "mailadresse"},
+	"Phone":           {"telefon", "tel", "phone", "telephone", "telefonnummer", "phonenumber", "mobile", "handy", "mobil"},
+	"VATNumber":       {"ustid", "ustidnr", "vat", "vatnumber", "vatid", "umsatzsteuerid"},
+}
+
+// shipmentFieldSynonyms contains the synonyms of the ValueIDs which are not part of an address
+var shipmentFieldSynonyms = map[string][]string{
+	"Reference":             {"referenz", "reference", "bestellnummer", "bestellnr", "ordernumber", "orderno", "order", "orderid", "auftragsnummer", "auftragsnr"},
+	"ShipmentDate":          {"versanddatum", "shipmentdate", "shippingdate", "datum", "date", "lieferdatum"},
+	"Carrier.Code":          {"carrier", "versanddienstleister", "dienstleister", "paketdienst"},
+	"Carrier.Product":       {"produkt", "product", "versandart", "shippingmethod", "versandprodukt"},
+	"Parcels.0.Weight":      {"gewicht", "weight", "gewichtkg", "weightkg", "gewichting", "weightg", "paketgewicht"},
+	"Parcels.0.Length":      {"laenge", "length"},
+	"Parcels.0.Width":       {"breite", "width"},
+	"Parcels.0.Height":      {"hoehe", "height"},
+	"Parcels.0.Description": {"inhalt", "content", "contents", "beschreibung", "description"},
+	"Customs.InvoiceNumber": {"rechnungsnummer", "rechnungsnr", "invoicenumber", "invoiceno", "invoice"},
+}
+
+var (
+	senderTokens   = []string{"absender", "sender", "shipper", "from", "abs"}
+	receiverTokens = []string{"empfaenger", "receiver", "recipient", "shipping", "delivery", "lieferadresse", "lieferung", "to", "emp", "ship"}
+)
+
+type mappingCandidate struct {
+	column     int
+	valueID    string
+	confidence float64
+}
+
+// inferMappings matches the header fields against the synonyms. Every ValueID is mapped at most once.
+func inferMappings(header []string) ([]*InferredMapping, []string) {
+	candidates := make([]mappingCandidate, 0)
+	for i, h := range header {
+		candidates = append(candidates, matchHeader(i, h)...)
+	}
+
+	// Assign the best candidates first
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.confidence != b.confidence {
+			return a.confidence > b.confidence
+		}
+		if a.column != b.column {
+			return a.column < b.column
+		}
+		return a.valueID < b.valueID
+	})
+
+	assigned := make(map[int]mappingCandidate)
+	used := make(map[string]bool)
+	for _, c := range candidates {
+		if _, ok := assigned[c.column]; ok || used[c.valueID] {
+			continue
+		}
+		assigned[c.column] = c
+		used[c.valueID] = true
+	}
+
+	mappings := make([]*InferredMapping, 0, len(assigned))
+	unmapped := make([]string, 0)
+	for i, h := range header {
+		c, ok := assigned[i]
+		if !ok {
+			unmapped = append(unmapped, h)
+			continue
+		}
+
+		mappings = append(mappings, &InferredMapping{
+			Mapping:    &Mapping{HeaderField: h, ValueID: c.valueID},
+			Confidence: c.confidence,
+		})
+	}
+	return mappings, unmapped
+}
+
+// matchHeader returns all ValueIDs which match a header field
+func matchHeader(column int, header string) []mappingCandidate {
+	tokens := headerTokens(header)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	res := make([]mappingCandidate, 0)
+	for valueID, synonyms := range shipmentFieldSynonyms {
+		if conf := synonymConfidence(strings.Join(tokens, ""), synonyms); conf > 0 {
+			res = append(res, mappingCandidate{column, valueID, conf})
+		}
+	}
+
+	// Address fields belong to the receiver unless the header names the sender
+	role, roleFactor := "Receiver", 0.9
+	rest := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		switch r, stripped := stripRole(t); r {
+		case "":
+			rest = append(rest, t)
+		default:
+			role, roleFactor = r, 1
+			if stripped != "" {
+				rest = append(rest, stripped)
+			}
+		}
+	}
+
+	if len(rest) == 0 {
+		return res
+	}
+
+	for field, synonyms := range addressFieldSynonyms {
+		if conf := synonymConfidence(strings.Join(rest, ""), synonyms); conf > 0 {
+			res = append(res, mappingCandidate{column, role + "." + field, conf * roleFactor})
+		}
+	}
+	return res
+}
+
+// stripRole detects a sender or receiver token or prefix (e.g. Empfängername)
+func stripRole(token string) (role string, rest string) {
+	for _, roles := range []struct {
+		role   string
+		tokens []string
+	}{{"Sender", senderTokens}, {"Receiver", receiverTokens}} {
+		for _, t := range roles.tokens {
+			if token == t {
+				return roles.role, ""
+			}
+
+			if len(t) >= 5 && strings.HasPrefix(token, t) {
+				return roles.role, strings.TrimPrefix(token, t)
+			}
+		}
+	}
+	return "", token
+}
+
+// synonymConfidence returns how well the normalized header matches one of the synonyms
+func synonymConfidence(header string, synonyms []string) float64 {
+	best := 0.0
+	for _, s := range synonyms {
+		var conf float64
+		switch {
+		case header == s:
+			conf = 1
+		case len(s) >= 4 && (strings.HasPrefix(header, s) || strings.HasSuffix(header, s)):
+			conf = 0.7
+		default:
+			maxLen := len(header)
+			if len(s) > maxLen {
+				maxLen = len(s)
+			}
+
+			if maxLen >= 5 {
+				if sim := 1 - float64(levenshtein(header, s))/float64(maxLen); sim >= 0.75 {
+					conf = sim * 0.8
+				}
+			}
+		}
+
+		if conf > best {
+			best = conf
+		}
+	}
+	return best
+}
+
+// headerTokens normalizes a header field into lowercase ASCII tokens (e.g. "Empfänger E-Mail" → [empfaenger e mail])
+func headerTokens(header string) []string {
+	r := strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss", "Ä", "ae", "Ö", "oe", "Ü", "ue", "é", "e", "è", "e")
+	header = strings.ToLower(r.Replace(strings.TrimSpace(header)))
+
+	tokens := strings.FieldsFunc(header, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	// Join single letters (e.g. "e mail" → "email", "c o" → "co")
+	res := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if len(tokens[i]) == 1 && i+1 < len(tokens) {
+			tokens[i+1] = tokens[i] + tokens[i+1]
+			continue
+		}
+		res = append(res, tokens[i])
+	}
+	return res
+}
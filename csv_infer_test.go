@@ -0,0 +1,80 @@
+package shippinglabel
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferCSVProfile(t *testing.T) {
+	sample := "Bestellnummer;Vorname;Nachname;Strasse;Hausnummer;PLZ;Ort;Land;E-Mail;Gewicht;Versanddatum;Absender Firma;Foo\n" +
+		"1001;Max;Mustermann;Hauptstraße;12a;10115;Berlin;DE;max@example.org;1,5;16.01.2023;Shop GmbH;x\n"
+
+	res, err := InferCSVProfile(strings.NewReader(sample))
+	isNoError(t, err)
+	isEqual(t, ";", res.Profile.Delimiter)
+	isEqual(t, EncodingUTF8, res.Profile.Encoding)
+	isEqual(t, "DD.MM.YYYY", res.Profile.DateFormat)
+	isEqual(t, []string{"Foo"}, res.Unmapped)
+	isNoError(t, res.Profile.Validate())
+
+	expected := map[string]string{
+		"Bestellnummer":  "Reference",
+		"Vorname":        "Receiver.FirstName",
+		"Strasse":        "Receiver.Street",
+		"Hausnummer":     "Receiver.StreetNumber",
+		"PLZ":            "Receiver.PostalCode",
+		"E-Mail":         "Receiver.Mail",
+		"Gewicht":        "Parcels.0.Weight",
+		"Versanddatum":   "ShipmentDate",
+		"Absender Firma": "Sender.Company",
+	}
+	for _, m := range res.Mappings {
+		if valueID, ok := expected[m.Mapping.HeaderField]; ok && valueID != m.Mapping.ValueID {
+			t.Fatalf("expected %s => %s, got %s", m.Mapping.HeaderField, valueID, m.Mapping.ValueID)
+		}
+
+		if m.Confidence <= 0 || m.Confidence > 1 {
+			t.Fatalf("invalid confidence: %v", m.Confidence)
+		}
+	}
+
+	// English headers, comma delimiter and ISO-8859-1
+	sample = "Order ID,Recipient Name,Street,Zip,City,Country,Ship Date\nA-1,J\xfcrgen,Main St,12345,Springfield,US,2023-01-16 10:00\n"
+	res, err = InferCSVProfile(strings.NewReader(sample))
+	isNoError(t, err)
+	isEqual(t, ",", res.Profile.Delimiter)
+	isEqual(t, EncodingISO88591, res.Profile.Encoding)
+	isEqual(t, "YYYY-MM-DD HH:mm", res.Profile.DateFormat)
+	isEqual(t, 7, len(res.Mappings))
+	isEqual(t, "Receiver.LastName", res.Mappings[1].Mapping.ValueID)
+}
+
+func TestInferCSVProfile_Sample(t *testing.T) {
+	// ISO 8601 date times with a T separator
+	res, err := InferCSVProfile(strings.NewReader("Order;Ship Date\nA-1;2023-01-16T10:30\n"))
+	isNoError(t, err)
+	isEqual(t, "YYYY-MM-DDTHH:mm", res.Profile.DateFormat)
+
+	date, err := time.Parse(DateLayoutFromFormat(res.Profile.DateFormat), "2023-01-16T10:30")
+	isNoError(t, err)
+	isEqual(t, 10, date.Hour())
+
+	// The sample is cut after the last full line, not within the "ü" of the last row
+	row := "A-1;Jürgen;Hauptstraße 1\n"
+	header := "Order;Name;Street"
+	for (maxInferSampleSize-len(header)-1)%len(row) != strings.Index(row, "ü")+1 {
+		header += "x"
+	}
+	sample := header + "\n" + strings.Repeat(row, maxInferSampleSize/len(row)+1)
+	res, err = InferCSVProfile(strings.NewReader(sample))
+	isNoError(t, err)
+	isEqual(t, EncodingUTF8, res.Profile.Encoding)
+	isEqual(t, ";", res.Profile.Delimiter)
+
+	// A sample without a line break is cut after the last full character
+	isEqual(t, []byte("ab"), trimPartialRune([]byte("ab\xc3")))
+	isEqual(t, []byte("aü"), trimPartialRune([]byte("aü")))
+	isEqual(t, []byte("a"), trimPartialRune([]byte("a\xf0\x9f\x98")))
+	isEqual(t, []byte("a\xff"), trimPartialRune([]byte("a\xff")))
+}
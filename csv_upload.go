@@ -0,0 +1,228 @@
+package shippinglabel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// CSVUploadOptions configures UploadCSV
+type CSVUploadOptions struct {
+	ChunkRows   int // Maximum number of data rows per request. Default: 1000
+	ChunkBytes  int // Maximum size of a request. Default: 8 MB
+	Concurrency int // Number of parallel uploads. Default: 2
+}
+
+// CSVLineResult is the queue item or the error of a CSV data row. Err wraps ErrUnknownUploadResult if the row was
+// sent, but the response did not show whether it was queued.
+type CSVLineResult struct {
+	Line int // Line number of the row in the CSV file (the header is line 1)
+	Item *ShipmentQueueItem
+	Err  error
+}
+
+// CSVUploadResult contains a CSVLineResult for every non-empty data row in the order of the file
+type CSVUploadResult struct {
+	Lines []*CSVLineResult
+}
+
+// Items returns all created queue items
+func (m *CSVUploadResult) Items() []*ShipmentQueueItem {
+	res := make([]*ShipmentQueueItem, 0, len(m.Lines))
+	for _, l := range m.Lines {
+		if l.Err == nil {
+			res = append(res, l.Item)
+		}
+	}
+	return res
+}
+
+// Failed returns the rows which could not be uploaded and can be uploaded again
+func (m *CSVUploadResult) Failed() []*CSVLineResult {
+	res := make([]*CSVLineResult, 0)
+	for _, l := range m.Lines {
+		if l.Err != nil && !errors.Is(l.Err, ErrUnknownUploadResult) {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// Unknown returns the rows of chunks whose response did not match the rows. They may have been queued and must not be
+// uploaded again before the shipment queue was checked, otherwise duplicate shipments can be created.
+func (m *CSVUploadResult) Unknown() []*CSVLineResult {
+	res := make([]*CSVLineResult, 0)
+	for _, l := range m.Lines {
+		if errors.Is(l.Err, ErrUnknownUploadResult) {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// csvChunk is a part of the CSV file with the repeated header
+type csvChunk struct {
+	data  *bytes.Buffer
+	lines []int // Line numbers of the data rows
+}
+
+// UploadCSV reads a CSV file as stream, splits it into row-aligned chunks which repeat the header and uploads them with
+// bounded concurrency. opts can be nil. An error is returned if the file cannot be read or the context was cancelled,
+// failed uploads are reported per line. Rows with an unknown result are reported by Unknown and not by Failed. The
+// result contains the chunks which were uploaded before an error.
+func (c *APIContext) UploadCSV(ctx context.Context, r io.Reader, csvProfileID int, opts *CSVUploadOptions) (*CSVUploadResult, error) {
+	o := CSVUploadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.ChunkRows <= 0 {
+		o.ChunkRows = 1000
+	}
+	if o.ChunkBytes <= 0 {
+		o.ChunkBytes = 8 << 20
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 2
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	br := bufio.NewReader(r)
+	header, _, err := readCSVRow(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrMissingCSVHeader
+		}
+		return nil, err
+	}
+
+	res := &CSVUploadResult{}
+	mutex := sync.Mutex{}
+	chunks := make(chan *csvChunk)
+	wg := sync.WaitGroup{}
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				lines := c.uploadCSVChunk(ctx, chunk, csvProfileID)
+				mutex.Lock()
+				res.Lines = append(res.Lines, lines...)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	readErr := splitCSV(ctx, br, header, o, chunks)
+	close(chunks)
+	wg.Wait()
+
+	sort.Slice(res.Lines, func(i, j int) bool {
+		return res.Lines[i].Line < res.Lines[j].Line
+	})
+
+	if readErr != nil {
+		return res, readErr
+	}
+	return res, ctx.Err()
+}
+
+// splitCSV reads the data rows and sends the chunks to the channel
+func splitCSV(ctx context.Context, br *bufio.Reader, header []byte, o CSVUploadOptions, chunks chan<- *csvChunk) error {
+	line := 2
+	var chunk *csvChunk
+	send := func() error {
+		if chunk == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunks <- chunk:
+			chunk = nil
+			return nil
+		}
+	}
+
+	for {
+		row, n, err := readCSVRow(br)
+		if errors.Is(err, io.EOF) {
+			return send()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(bytes.TrimSpace(row)) > 0 {
+			if chunk != nil && (len(chunk.lines) >= o.ChunkRows || chunk.data.Len()+len(row) > o.ChunkBytes) {
+				if err = send(); err != nil {
+					return err
+				}
+			}
+
+			if chunk == nil {
+				chunk = &csvChunk{data: bytes.NewBuffer(append([]byte(nil), header...))}
+			}
+			chunk.data.Write(row)
+			chunk.lines = append(chunk.lines, line)
+		}
+		line += n
+	}
+}
+
+// uploadCSVChunk uploads a chunk and pairs the queue items with the line numbers. The result of all rows is unknown if
+// the number of queue items does not match the rows.
+func (c *APIContext) uploadCSVChunk(ctx context.Context, chunk *csvChunk, csvProfileID int) []*CSVLineResult {
+	items, err := c.UploadCSVFile(ctx, chunk.data.Bytes(), csvProfileID)
+	if err == nil && len(items) != len(chunk.lines) {
+		err = fmt.Errorf("%w: expected %d queue items, got %d", ErrUnknownUploadResult, len(chunk.lines), len(items))
+	}
+
+	res := make([]*CSVLineResult, 0, len(chunk.lines))
+	for i, line := range chunk.lines {
+		l := &CSVLineResult{Line: line, Err: err}
+		if err == nil {
+			l.Item = items[i]
+		}
+		res = append(res, l)
+	}
+	return res
+}
+
+// readCSVRow reads a CSV row including quoted line breaks. The row always ends with a line break. n is the number of
+// physical lines of the row.
+func readCSVRow(br *bufio.Reader) (row []byte, n int, err error) {
+	quoted := false
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			if len(row) > 0 && errors.Is(err, io.EOF) {
+				return append(row, '\n'), n, nil
+			}
+			return nil, n, err
+		}
+
+		row = append(row, line...)
+		n++
+		quoted = quoted != (bytes.Count(line, []byte{'"'})%2 == 1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, n, err
+		}
+
+		if !quoted || err != nil {
+			if row[len(row)-1] != '\n' {
+				row = append(row, '\n')
+			}
+			return row, n, nil
+		}
+	}
+}
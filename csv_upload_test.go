@@ -0,0 +1,105 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAPIContext_UploadCSV(t *testing.T) {
+	mutex := sync.Mutex{}
+	var requests, nextID int
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		rows := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+		if rows[0] != "Reference;Name" {
+			t.Errorf("expected header, got %q", rows[0])
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests++
+		if strings.Contains(string(b), "FAIL") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"invalid row"}`))
+			return
+		}
+
+		items := make([]*ShipmentQueueItem, 0)
+		if strings.Contains(string(b), "SHORT") {
+			_ = json.NewEncoder(w).Encode(items)
+			return
+		}
+
+		for _, row := range rows[1:] {
+			if strings.HasPrefix(row, "multi") {
+				continue // Second line of a quoted field
+			}
+			nextID++
+			items = append(items, &ShipmentQueueItem{ID: nextID})
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+
+	csvData := "Reference;Name\n1;a\n2;\"b\nmulti\"\n\n3;FAIL\n4;d\n5;e"
+	res, err := api.UploadCSV(context.Background(), strings.NewReader(csvData), 1, &CSVUploadOptions{ChunkRows: 2, Concurrency: 2})
+	isNoError(t, err)
+	isEqual(t, 3, requests)
+	isEqual(t, 5, len(res.Lines))
+
+	lines := make([]int, 0)
+	for _, l := range res.Lines {
+		lines = append(lines, l.Line)
+	}
+	isEqual(t, []int{2, 3, 6, 7, 8}, lines)
+	isEqual(t, 3, len(res.Items()))
+	isEqual(t, 2, len(res.Failed()))
+	isEqual(t, 6, res.Failed()[0].Line)
+	isEqual(t, 0, len(res.Unknown()))
+
+	// The rows of a response without all queue items may have been queued
+	res, err = api.UploadCSV(context.Background(), strings.NewReader("Reference;Name\n1;SHORT\n2;b\n3;c"), 1, &CSVUploadOptions{ChunkRows: 2})
+	isNoError(t, err)
+	isEqual(t, 1, len(res.Items()))
+	isEqual(t, 0, len(res.Failed()))
+	unknown := res.Unknown()
+	isEqual(t, 2, len(unknown))
+	isEqual(t, 3, unknown[1].Line)
+	if !errors.Is(unknown[0].Err, ErrUnknownUploadResult) {
+		t.Fatalf("expected unknown result, got %v", unknown[0].Err)
+	}
+}
+
+// failingReader returns the data and then a read error
+type failingReader struct {
+	r io.Reader
+}
+
+func (m *failingReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, errReadFailed
+	}
+	return n, err
+}
+
+var errReadFailed = errors.New("read failed")
+
+func TestAPIContext_UploadCSV_ReadError(t *testing.T) {
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]*ShipmentQueueItem{{ID: 1}, {ID: 2}})
+	}))
+
+	r := &failingReader{r: strings.NewReader("Reference;Name\n1;a\n2;b\n3;c\n4;d")}
+	res, err := api.UploadCSV(context.Background(), r, 1, &CSVUploadOptions{ChunkRows: 2, Concurrency: 1})
+	if !errors.Is(err, errReadFailed) {
+		t.Fatalf("expected read error, got %v", err)
+	}
+	isNotNil(t, res)
+	isEqual(t, 2, len(res.Items()))
+}
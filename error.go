@@ -1,13 +1,52 @@
 package shippinglabel
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
 
 var (
-	ErrRequiredClientIDAndSecret = errors.New("clientID and clientSecret are required")
-	ErrRequiredClient            = errors.New("client is required")
-	ErrRequiredToken             = errors.New("token is required")
-	ErrRequiredID                = errors.New("id is required")
-	ErrWrongType                 = errors.New("wrong type")
+	ErrRequiredClientIDAndSecret   = errors.New("clientID and clientSecret are required")
+	ErrRequiredClient              = errors.New("client is required")
+	ErrRequiredToken               = errors.New("token is required")
+	ErrRequiredShipment            = errors.New("shipment is required")
+	ErrRequiredParcel              = errors.New("parcel is required")
+	ErrRequiredProduct             = errors.New("product is required")
+	ErrRequiredCSVProfile          = errors.New("csv profile is required")
+	ErrRequiredAddress             = errors.New("address is required")
+	ErrRequiredCustoms             = errors.New("customs items are required")
+	ErrRequiredID                  = errors.New("id is required")
+	ErrWrongType                   = errors.New("wrong type")
+	ErrInvalidCarrierParameter     = errors.New("invalid carrier parameter")
+	ErrInvalidServiceParameter     = errors.New("invalid service parameter")
+	ErrUnknownCarrier              = errors.New("unknown carrier")
+	ErrUnknownProduct              = errors.New("unknown carrier product")
+	ErrInvalidProduct              = errors.New("invalid carrier product")
+	ErrRequiredPreviousCredentials = errors.New("previous credentials are required for a rollback")
+	ErrRotationFailed              = errors.New("credential rotation failed")
+	ErrJobCompleted                = errors.New("job has already completed")
+	ErrJobNotDone                  = errors.New("job has not finished")
+	ErrEmptyResponse               = errors.New("empty response")
+	ErrBulkResponseMismatch        = errors.New("bulk response does not match the request")
+	ErrUnknownUploadResult         = errors.New("upload result unknown")
+	ErrUnknownValueID              = errors.New("unknown value id")
+	ErrMissingCSVHeader            = errors.New("missing csv header field")
+	ErrInvalidDelimiter            = errors.New("invalid csv delimiter")
+	ErrUnsupportedEncoding         = errors.New("unsupported encoding")
+	ErrNotModified                 = errors.New("not modified")
+	ErrNoEligibleProduct           = errors.New("no eligible carrier product")
+	ErrUnknownTransitTime          = errors.New("delivery time unknown")
+	ErrInvalidPostNumber           = errors.New("invalid post number")
+	ErrInvalidPickupPoint          = errors.New("invalid pickup point")
+	ErrUnsupportedPickupPoint      = errors.New("unsupported pickup point")
+	ErrInvalidPhone                = errors.New("invalid phone number")
+	ErrInvalidMail                 = errors.New("invalid mail address")
+	ErrInvalidVATNumber            = errors.New("invalid vat number")
+	ErrInvalidEORI                 = errors.New("invalid eori number")
+	ErrDuplicateSyncKey            = errors.New("duplicate sync key")
+	ErrInvalidVCard                = errors.New("invalid vcard")
+	ErrUnknownAddressLayout        = errors.New("unknown address csv layout")
 )
 
 type Error struct {
@@ -20,3 +59,13 @@ type Error struct {
 func (m *Error) Error() string {
 	return m.Message
 }
+
+// StatusError is returned for error responses without a body. Such responses returned io.EOF before, callers which
+// compared the error with io.EOF must check for *StatusError instead. Responses with a body still return *Error.
+type StatusError struct {
+	StatusCode int
+}
+
+func (m *StatusError) Error() string {
+	return fmt.Sprintf("%d %s", m.StatusCode, http.StatusText(m.StatusCode))
+}
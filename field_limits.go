@@ -0,0 +1,208 @@
+package shippinglabel
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldLimits are the maximum lengths (in characters) of the address fields and the reference of a carrier. 0 means
+// unlimited.
+type FieldLimits struct {
+	Company         int // Name 1
+	FirstName       int // Name 2
+	LastName        int // Name 3
+	Street          int
+	StreetNumber    int
+	AddressAddition int
+	PostalCode      int
+	City            int
+	Mail            int
+	Phone           int
+	Reference       int
+	Latin1          bool // Only ISO 8859-1 characters are accepted
+}
+
+// DefaultFieldLimits contains the field limits of the carriers
+var DefaultFieldLimits = map[CarrierCode]*FieldLimits{
+	CarrierDHL: {Company: 50, FirstName: 50, LastName: 50, Street: 50, StreetNumber: 10, AddressAddition: 60,
+		PostalCode: 10, City: 40, Mail: 80, Phone: 20, Reference: 35, Latin1: true},
+	CarrierDP: {Company: 40, FirstName: 40, LastName: 40, Street: 40, StreetNumber: 10, AddressAddition: 40,
+		PostalCode: 10, City: 40, Mail: 80, Phone: 20, Reference: 35, Latin1: true},
+	CarrierDPD: {Company: 35, FirstName: 35, LastName: 35, Street: 35, StreetNumber: 8, AddressAddition: 35,
+		PostalCode: 9, City: 35, Mail: 50, Phone: 30, Reference: 35},
+	CarrierGLS: {Company: 40, FirstName: 40, LastName: 40, Street: 40, StreetNumber: 10, AddressAddition: 40,
+		PostalCode: 10, City: 40, Mail: 80, Phone: 40, Reference: 40},
+	CarrierHermes: {Company: 30, FirstName: 30, LastName: 30, Street: 50, StreetNumber: 8, AddressAddition: 50,
+		PostalCode: 8, City: 30, Mail: 80, Phone: 20, Reference: 20, Latin1: true},
+	CarrierUPS: {Company: 35, FirstName: 35, LastName: 35, Street: 35, StreetNumber: 10, AddressAddition: 35,
+		PostalCode: 9, City: 30, Mail: 50, Phone: 15, Reference: 35},
+	CarrierPostAT: {Company: 40, FirstName: 40, LastName: 40, Street: 40, StreetNumber: 10, AddressAddition: 40,
+		PostalCode: 10, City: 40, Mail: 80, Phone: 20, Reference: 35, Latin1: true},
+	CarrierDHLExpress: {Company: 60, FirstName: 45, LastName: 45, Street: 45, StreetNumber: 10, AddressAddition: 45,
+		PostalCode: 12, City: 35, Mail: 70, Phone: 25, Reference: 35},
+}
+
+// FieldWarning is a change or a problem which was found by FitAddress or FitShipment
+type FieldWarning struct {
+	Field  string
+	Old    string
+	New    string
+	Reason string
+}
+
+func (m FieldWarning) String() string {
+	return fmt.Sprintf("%s: %s", m.Field, m.Reason)
+}
+
+type fitLine struct {
+	name  string
+	ptr   *string
+	limit int
+}
+
+// FitAddress transliterates the address if the carrier accepts only Latin-1 and fits the fields into the limits. The
+// overflow of the name lines and the street is moved into empty FirstName, LastName or AddressAddition lines. Fields
+// without space are truncated, postal codes, mails and phone numbers are only reported.
+func (m *FieldLimits) FitAddress(a *Address) []FieldWarning {
+	return m.fitAddress(a, "")
+}
+
+func (m *FieldLimits) fitAddress(a *Address, prefix string) []FieldWarning {
+	if a == nil {
+		return nil
+	}
+
+	f := &fitter{prefix: prefix}
+	company := fitLine{"Company", &a.Company, m.Company}
+	firstName := fitLine{"FirstName", &a.FirstName, m.FirstName}
+	lastName := fitLine{"LastName", &a.LastName, m.LastName}
+	street := fitLine{"Street", &a.Street, m.Street}
+	streetNumber := fitLine{"StreetNumber", &a.StreetNumber, m.StreetNumber}
+	addition := fitLine{"AddressAddition", &a.AddressAddition, m.AddressAddition}
+	city := fitLine{"City", &a.City, m.City}
+	postalCode := fitLine{"PostalCode", &a.PostalCode, m.PostalCode}
+	mail := fitLine{"Mail", &a.Mail, m.Mail}
+	phone := fitLine{"Phone", &a.Phone, m.Phone}
+
+	if m.Latin1 {
+		for _, l := range []fitLine{company, firstName, lastName, street, streetNumber, addition, city, postalCode, mail, phone} {
+			f.transliterate(l)
+		}
+	}
+
+	f.overflow(company, firstName, lastName, addition)
+	f.overflow(firstName, lastName, addition)
+	f.overflow(lastName, addition)
+	f.overflow(street, addition)
+	f.overflow(streetNumber, addition)
+	f.overflow(addition)
+	f.overflow(city)
+
+	for _, l := range []fitLine{postalCode, mail, phone} {
+		if l.limit > 0 && utf8.RuneCountInString(*l.ptr) > l.limit {
+			f.warn(l.name, *l.ptr, *l.ptr, fmt.Sprintf("exceeds %d characters", l.limit))
+		}
+	}
+	return f.warnings
+}
+
+// FitShipment fits the sender, the receiver and the reference of the shipment
+func (m *FieldLimits) FitShipment(s *Shipment) []FieldWarning {
+	if s == nil {
+		return nil
+	}
+
+	warnings := m.fitAddress(s.Sender, "Sender.")
+	warnings = append(warnings, m.fitAddress(s.Receiver, "Receiver.")...)
+
+	f := &fitter{}
+	reference := fitLine{"Reference", &s.Reference, m.Reference}
+	if m.Latin1 {
+		f.transliterate(reference)
+	}
+	f.overflow(reference)
+	return append(warnings, f.warnings...)
+}
+
+// FitShipment fits the shipment into the DefaultFieldLimits of its carrier. Shipments without a known carrier are not
+// changed.
+func FitShipment(s *Shipment) []FieldWarning {
+	if s == nil || s.Carrier == nil {
+		return nil
+	}
+
+	limits, ok := DefaultFieldLimits[s.Carrier.Code]
+	if !ok {
+		return nil
+	}
+	return limits.FitShipment(s)
+}
+
+type fitter struct {
+	prefix   string
+	warnings []FieldWarning
+}
+
+func (f *fitter) warn(field, old, value, reason string) {
+	f.warnings = append(f.warnings, FieldWarning{Field: f.prefix + field, Old: old, New: value, Reason: reason})
+}
+
+func (f *fitter) transliterate(l fitLine) {
+	if t := Transliterate(*l.ptr); t != *l.ptr {
+		f.warn(l.name, *l.ptr, t, "transliterated to Latin-1")
+		*l.ptr = t
+	}
+}
+
+// overflow moves the overflow of the line into the first target which is empty. AddressAddition targets are also used
+// if the overflow can be appended. The line is truncated if no target fits.
+func (f *fitter) overflow(l fitLine, targets ...fitLine) {
+	if l.limit <= 0 || utf8.RuneCountInString(*l.ptr) <= l.limit {
+		return
+	}
+
+	old := *l.ptr
+	head, rest := splitWords(old, l.limit)
+	for _, t := range targets {
+		value := rest
+		switch {
+		case *t.ptr == "":
+		case t.name == "AddressAddition":
+			value = *t.ptr + ", " + rest
+		default:
+			continue
+		}
+
+		if t.limit > 0 && utf8.RuneCountInString(value) > t.limit {
+			continue
+		}
+
+		f.warn(l.name, old, head, fmt.Sprintf("overflow moved to %s", t.name))
+		f.warn(t.name, *t.ptr, value, fmt.Sprintf("overflow of %s", l.name))
+		*l.ptr, *t.ptr = head, value
+		return
+	}
+
+	truncated := string([]rune(old)[:l.limit])
+	f.warn(l.name, old, truncated, fmt.Sprintf("truncated to %d characters", l.limit))
+	*l.ptr = truncated
+}
+
+// splitWords splits s after the last word which fits into limit characters. A word which is longer than the limit is
+// split.
+func splitWords(s string, limit int) (head string, rest string) {
+	r := []rune(s)
+	if len(r) <= limit {
+		return s, ""
+	}
+
+	cut := limit
+	for i := limit; i > 0; i-- {
+		if r[i] == ' ' {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRight(string(r[:cut]), " ,"), strings.TrimLeft(string(r[cut:]), " ,")
+}
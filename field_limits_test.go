@@ -0,0 +1,44 @@
+package shippinglabel
+
+import "testing"
+
+func TestFitShipment(t *testing.T) {
+	s := &Shipment{
+		Carrier: &Carrier{Code: CarrierDPD},
+		Receiver: &Address{
+			Company:  "Internationale Handelsgesellschaft für Maschinenbau mbH",
+			LastName: "Max Mustermann",
+			Street:   "Straße der Pariser Kommune mit langem Namen",
+			City:     "Frankfurt am Main Sachsenhausen Süd Mitte",
+		},
+		Reference: "Bestellung 123456789 vom 01.01.2024 über den Onlineshop",
+	}
+
+	warnings := FitShipment(s)
+	r := s.Receiver
+	isEqual(t, r.Company, "Internationale Handelsgesellschaft")
+	isEqual(t, r.FirstName, "für Maschinenbau mbH")
+	isEqual(t, r.LastName, "Max Mustermann")
+	isEqual(t, r.Street, "Straße der Pariser Kommune mit")
+	isEqual(t, r.AddressAddition, "langem Namen")
+	isEqual(t, r.City, "Frankfurt am Main Sachsenhausen Süd")
+	isEqual(t, len([]rune(s.Reference)), 35)
+
+	fields := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		fields = append(fields, w.Field)
+	}
+	for _, f := range []string{"Receiver.Company", "Receiver.FirstName", "Receiver.Street", "Receiver.AddressAddition", "Receiver.City", "Reference"} {
+		if !contains(fields, f) {
+			t.Errorf("expected warning for %s, got %v", f, warnings)
+		}
+	}
+
+	// DHL accepts only Latin-1
+	s = &Shipment{Carrier: &Carrier{Code: CarrierDHL}, Receiver: &Address{LastName: "Paweł Nowak", City: "Łódź", PostalCode: "90-001-12345678"}}
+	warnings = FitShipment(s)
+	isEqual(t, s.Receiver.LastName, "Pawel Nowak")
+	isEqual(t, s.Receiver.City, "Lódz")
+	isEqual(t, s.Receiver.PostalCode, "90-001-12345678")
+	isEqual(t, len(warnings), 3)
+}
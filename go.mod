@@ -2,6 +2,7 @@ module github.com/dewaco/shippinglabel
 
 go 1.19
 
-require golang.org/x/net v0.5.0
-
-require golang.org/x/text v0.6.0 // indirect
+require (
+	golang.org/x/net v0.5.0
+	golang.org/x/text v0.6.0
+)
@@ -1,6 +1,12 @@
 package shippinglabel
 
-import "time"
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
 
 type JobStatusCode string
 
@@ -21,3 +27,246 @@ type ShipmentJob struct {
 	TotalQueueItems     int                  `json:"totalQueueItems,omitempty"`
 	ProcessedQueueItems int                  `json:"processedQueueItems,omitempty"`
 }
+
+// IsDone returns whether the job has completed or was cancelled
+func (m *ShipmentJob) IsDone() bool {
+	return m.Status == JobStatusCompleted || m.Status == JobStatusCancelled
+}
+
+// JobWaitOptions configures the polling of WaitForJob
+type JobWaitOptions struct {
+	InitialInterval time.Duration // Default: 1 second
+	MaxInterval     time.Duration // Default: 30 seconds
+	Multiplier      float64       // Growth of the interval after each poll. Default: 1.5
+
+	// Progress is called whenever the number of processed queue items changes
+	Progress func(processed int, total int)
+}
+
+// withDefaults returns a copy of the options with the default values
+func (m *JobWaitOptions) withDefaults() JobWaitOptions {
+	o := JobWaitOptions{}
+	if m != nil {
+		o = *m
+	}
+
+	if o.InitialInterval <= 0 {
+		o.InitialInterval = time.Second
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = 30 * time.Second
+	}
+	if o.MaxInterval < o.InitialInterval {
+		o.MaxInterval = o.InitialInterval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = 1.5
+	}
+	return o
+}
+
+// WaitForJob polls a job with backoff until its status is COMPLETED or CANCELLED. opts can be nil.
+func (c *APIContext) WaitForJob(ctx context.Context, id int, opts *JobWaitOptions) (*ShipmentJob, error) {
+	o := opts.withDefaults()
+	interval := o.InitialInterval
+	processed, total := -1, -1
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		job, err := c.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if job == nil {
+			return nil, fmt.Errorf("%w: job %d", ErrEmptyResponse, id)
+		}
+
+		if o.Progress != nil && (job.ProcessedQueueItems != processed || job.TotalQueueItems != total) {
+			processed, total = job.ProcessedQueueItems, job.TotalQueueItems
+			o.Progress(processed, total)
+		}
+
+		if job.IsDone() {
+			return job, nil
+		}
+
+		timer.Reset(interval)
+		interval = time.Duration(float64(interval) * o.Multiplier)
+		if interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+	}
+}
+
+// ScheduleJob creates a job which processes the queue items at the execution time
+func (c *APIContext) ScheduleJob(ctx context.Context, queueItemIDs []int, at time.Time) (*ShipmentJob, error) {
+	if len(queueItemIDs) == 0 {
+		return nil, ErrRequiredID
+	}
+
+	job := &ShipmentJob{ExecutionTime: &at, QueueItems: make([]*ShipmentQueueItem, 0, len(queueItemIDs))}
+	for _, id := range queueItemIDs {
+		job.QueueItems = append(job.QueueItems, &ShipmentQueueItem{ID: id})
+	}
+	return c.CreateJob(ctx, job)
+}
+
+// CancelJob cancels a job which has not completed yet. Cancelling a cancelled job has no effect.
+func (c *APIContext) CancelJob(ctx context.Context, id int) (*ShipmentJob, error) {
+	job, err := c.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job == nil {
+		return nil, fmt.Errorf("%w: job %d", ErrEmptyResponse, id)
+	}
+
+	switch job.Status {
+	case JobStatusCancelled:
+		return job, nil
+	case JobStatusCompleted:
+		return job, ErrJobCompleted
+	}
+
+	job.Status = JobStatusCancelled
+	return job, c.UpdateJob(ctx, job)
+}
+
+// JobResult contains the shipments and labels of a finished job
+type JobResult struct {
+	Job       *ShipmentJob
+	Shipments []*Shipment
+	Failed    []*ShipmentQueueItem // Queue items without a created shipment
+	Labels    *bytes.Buffer        // Labels of all shipments in PDF format, nil if no shipment was created
+}
+
+// jobResultConcurrency is the number of parallel shipment requests of CollectJobResult
+const jobResultConcurrency = 4
+
+// CollectJobResult returns the shipments and labels of a finished job. The shipments are fetched in parallel.
+func (c *APIContext) CollectJobResult(ctx context.Context, id int) (*JobResult, error) {
+	job, err := c.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job == nil {
+		return nil, fmt.Errorf("%w: job %d", ErrEmptyResponse, id)
+	}
+
+	if !job.IsDone() {
+		return nil, ErrJobNotDone
+	}
+
+	res := &JobResult{Job: job}
+	items := make([]*ShipmentQueueItem, 0, len(job.QueueItems))
+	for _, item := range job.QueueItems {
+		if item == nil {
+			continue
+		}
+
+		if item.Shipment == nil || item.Shipment.ID == 0 {
+			res.Failed = append(res.Failed, item)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	shipments, err := c.getShipments(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(shipments))
+	for i, s := range shipments {
+		if s == nil || (s.Status != nil && s.Status.Error != nil) {
+			res.Failed = append(res.Failed, items[i])
+			continue
+		}
+
+		res.Shipments = append(res.Shipments, s)
+		ids = append(ids, s.ID)
+	}
+
+	if len(ids) > 0 {
+		if res.Labels, err = c.GetLabels(ctx, ids); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// getShipments fetches the shipments of the queue items with jobResultConcurrency parallel requests. The first error
+// cancels the remaining requests.
+func (c *APIContext) getShipments(ctx context.Context, items []*ShipmentQueueItem) ([]*Shipment, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	shipments := make([]*Shipment, len(items))
+	var firstErr error
+	mutex := sync.Mutex{}
+	sem := make(chan struct{}, jobResultConcurrency)
+	wg := sync.WaitGroup{}
+
+dispatch:
+	for i, item := range items {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, id int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			s, err := c.GetShipment(ctx, id)
+			if err != nil {
+				mutex.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mutex.Unlock()
+				cancel()
+				return
+			}
+			shipments[i] = s
+		}(i, item.Shipment.ID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+// RunJob schedules the queue items, waits until the job has finished and collects the shipments and labels
+func (c *APIContext) RunJob(ctx context.Context, queueItemIDs []int, at time.Time, opts *JobWaitOptions) (*JobResult, error) {
+	job, err := c.ScheduleJob(ctx, queueItemIDs, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = c.WaitForJob(ctx, job.ID, opts); err != nil {
+		return nil, err
+	}
+	return c.CollectJobResult(ctx, job.ID)
+}
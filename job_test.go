@@ -0,0 +1,126 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPIContext_WaitForJob(t *testing.T) {
+	var polls int
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		job := &ShipmentJob{ID: 7, Status: JobStatusRunning, TotalQueueItems: 3, ProcessedQueueItems: polls - 1}
+		if polls == 4 {
+			job.Status = JobStatusCompleted
+		}
+		_ = json.NewEncoder(w).Encode(job)
+	}))
+
+	var progress []int
+	opts := &JobWaitOptions{
+		InitialInterval: time.Millisecond,
+		Progress: func(processed int, total int) {
+			progress = append(progress, processed)
+			isEqual(t, 3, total)
+		},
+	}
+
+	job, err := api.WaitForJob(context.Background(), 7, opts)
+	isNoError(t, err)
+	isEqual(t, JobStatusCompleted, job.Status)
+	isEqual(t, []int{0, 1, 2, 3}, progress)
+}
+
+func TestAPIContext_RunJob(t *testing.T) {
+	at := time.Date(2023, 1, 16, 22, 0, 0, 0, time.UTC)
+	var job *ShipmentJob
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/shipments/jobs":
+			job = &ShipmentJob{}
+			_ = json.NewDecoder(r.Body).Decode(job)
+			if job.ExecutionTime == nil || !job.ExecutionTime.Equal(at) {
+				t.Errorf("expected execution time %s, got %v", at, job.ExecutionTime)
+			}
+			job.ID, job.Status = 3, JobStatusCompleted
+			job.QueueItems[0].Shipment = &Shipment{ID: 10}
+			_ = json.NewEncoder(w).Encode(job)
+		case r.URL.Path == "/shipments/jobs/3":
+			_ = json.NewEncoder(w).Encode(job)
+		case r.URL.Path == "/shipments/10":
+			_ = json.NewEncoder(w).Encode(&Shipment{ID: 10, ShipmentNumber: "0034"})
+		case r.URL.Path == "/shipments/labels/10":
+			_, _ = w.Write([]byte("%PDF"))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	res, err := api.RunJob(context.Background(), []int{1, 2}, at, &JobWaitOptions{InitialInterval: time.Millisecond})
+	isNoError(t, err)
+	isEqual(t, 1, len(res.Shipments))
+	isEqual(t, 1, len(res.Failed))
+	isEqual(t, "%PDF", res.Labels.String())
+
+	_, err = api.CancelJob(context.Background(), 3)
+	isEqual(t, ErrJobCompleted, err)
+}
+
+func TestAPIContext_WaitForJob_Empty(t *testing.T) {
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("null"))
+	}))
+
+	if _, err := api.WaitForJob(context.Background(), 7, nil); !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("expected empty response, got %v", err)
+	}
+
+	if _, err := api.CancelJob(context.Background(), 7); !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("expected empty response, got %v", err)
+	}
+}
+
+func TestAPIContext_CollectJobResult(t *testing.T) {
+	job := &ShipmentJob{ID: 5, Status: JobStatusCompleted}
+	for id := 1; id <= 10; id++ {
+		job.QueueItems = append(job.QueueItems, &ShipmentQueueItem{ID: id, Shipment: &Shipment{ID: 100 + id}})
+	}
+
+	var inFlight, maxInFlight int32
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/shipments/jobs/5":
+			_ = json.NewEncoder(w).Encode(job)
+		case strings.HasPrefix(r.URL.Path, "/shipments/labels/"):
+			_, _ = w.Write([]byte("%PDF"))
+		default:
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for m := atomic.LoadInt32(&maxInFlight); n > m && !atomic.CompareAndSwapInt32(&maxInFlight, m, n); {
+				m = atomic.LoadInt32(&maxInFlight)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			var id int
+			_, _ = fmt.Sscanf(r.URL.Path, "/shipments/%d", &id)
+			_ = json.NewEncoder(w).Encode(&Shipment{ID: id})
+		}
+	}))
+
+	res, err := api.CollectJobResult(context.Background(), 5)
+	isNoError(t, err)
+	isEqual(t, 10, len(res.Shipments))
+	isEqual(t, 101, res.Shipments[0].ID)
+	isEqual(t, 110, res.Shipments[9].ID)
+	if n := atomic.LoadInt32(&maxInFlight); n < 2 || n > jobResultConcurrency {
+		t.Fatalf("expected at most %d parallel requests, got %d", jobResultConcurrency, n)
+	}
+}
@@ -1,5 +1,7 @@
 package shippinglabel
 
+import "fmt"
+
 type CarrierMetadata struct {
 	Code         CarrierCode    `json:"carrierCode,omitempty"`
 	Name         string         `json:"name,omitempty"`
@@ -33,3 +35,68 @@ type LabelFormat struct {
 	LabelCountX     int    `json:"labelCountX,omitempty"`
 	LabelCountY     int    `json:"labelCountY,omitempty"`
 }
+
+// ValidateProduct checks a contract product against the product catalog of the carrier metadata
+func ValidateProduct(metadata []*CarrierMetadata, code CarrierCode, p *Product) error {
+	if p == nil {
+		return ErrRequiredProduct
+	}
+
+	var carrier *CarrierMetadata
+	for _, md := range metadata {
+		if md != nil && md.Code == code {
+			carrier = md
+			break
+		}
+	}
+
+	if carrier == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownCarrier, code)
+	}
+
+	var catalog *Product
+	for _, cp := range carrier.Products {
+		if cp != nil && cp.Product == p.Product {
+			catalog = cp
+			break
+		}
+	}
+
+	if catalog == nil {
+		return fmt.Errorf("%w: %s product %q", ErrUnknownProduct, code, p.Product)
+	}
+
+	limits := []struct {
+		name                   string
+		min, max               int
+		catalogMin, catalogMax int
+	}{
+		{"weight", p.MinWeight, p.MaxWeight, catalog.MinWeight, catalog.MaxWeight},
+		{"length", p.MinLength, p.MaxLength, catalog.MinLength, catalog.MaxLength},
+		{"width", p.MinWidth, p.MaxWidth, catalog.MinWidth, catalog.MaxWidth},
+		{"height", p.MinHeight, p.MaxHeight, catalog.MinHeight, catalog.MaxHeight},
+	}
+
+	for _, l := range limits {
+		if l.min != 0 && l.min < l.catalogMin {
+			return fmt.Errorf("%w: minimum %s %d is below %d", ErrInvalidProduct, l.name, l.min, l.catalogMin)
+		}
+
+		if l.max != 0 && l.catalogMax != 0 && l.max > l.catalogMax {
+			return fmt.Errorf("%w: maximum %s %d exceeds %d", ErrInvalidProduct, l.name, l.max, l.catalogMax)
+		}
+
+		if l.min != 0 && l.max != 0 && l.min > l.max {
+			return fmt.Errorf("%w: minimum %s %d exceeds the maximum %d", ErrInvalidProduct, l.name, l.min, l.max)
+		}
+	}
+
+	if p.IsInternational && !catalog.IsInternational {
+		return fmt.Errorf("%w: %s product %q is not available for international shipments", ErrInvalidProduct, code, p.Product)
+	}
+
+	if code == CarrierDHL && p.UserParticipation != "" && !participationRegexp.MatchString(p.UserParticipation) {
+		return fmt.Errorf("%w: participation must have 2 characters", ErrInvalidProduct)
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package shippinglabel
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+//go:embed metadata_snapshot.json
+var defaultMetadataSnapshot []byte
+
+// MetadataSnapshot is a point-in-time copy of the carrier metadata
+type MetadataSnapshot struct {
+	ETag     string             `json:"etag,omitempty"`
+	Fetched  time.Time          `json:"fetched"`
+	Carriers []*CarrierMetadata `json:"carriers"`
+}
+
+// DefaultMetadataSnapshot returns the metadata snapshot which is embedded in the package. It contains the products with
+// the published weight and size limits and the label formats of the carriers. Prices depend on the contract and are not
+// included, they are loaded from the REST API on the first refresh.
+func DefaultMetadataSnapshot() *MetadataSnapshot {
+	snap, err := LoadMetadataSnapshot(bytes.NewReader(defaultMetadataSnapshot))
+	if err != nil {
+		panic("shippinglabel: invalid embedded metadata snapshot: " + err.Error())
+	}
+	return snap
+}
+
+// LoadMetadataSnapshot reads a JSON snapshot which was written with MetadataSnapshot.Save
+func LoadMetadataSnapshot(r io.Reader) (*MetadataSnapshot, error) {
+	snap := &MetadataSnapshot{}
+	if err := json.NewDecoder(r).Decode(snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Clone returns a deep copy of the snapshot
+func (m *MetadataSnapshot) Clone() *MetadataSnapshot {
+	c := *m
+	c.Carriers = make([]*CarrierMetadata, 0, len(m.Carriers))
+	for _, carrier := range m.Carriers {
+		if carrier == nil {
+			continue
+		}
+
+		cm := *carrier
+		cm.Products = make([]*Product, 0, len(carrier.Products))
+		for _, p := range carrier.Products {
+			if p != nil {
+				cp := *p
+				cm.Products = append(cm.Products, &cp)
+			}
+		}
+
+		cm.LabelFormats = make([]*LabelFormat, 0, len(carrier.LabelFormats))
+		for _, f := range carrier.LabelFormats {
+			if f != nil {
+				cf := *f
+				cm.LabelFormats = append(cm.LabelFormats, &cf)
+			}
+		}
+		c.Carriers = append(c.Carriers, &cm)
+	}
+	return &c
+}
+
+// Save writes the snapshot as JSON
+func (m *MetadataSnapshot) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Carrier returns the metadata of a carrier or nil
+func (m *MetadataSnapshot) Carrier(code CarrierCode) *CarrierMetadata {
+	for _, c := range m.Carriers {
+		if c != nil && c.Code == code {
+			return c
+		}
+	}
+	return nil
+}
+
+// Product returns a carrier product by its product code or nil
+func (m *MetadataSnapshot) Product(code CarrierCode, product string) *Product {
+	c := m.Carrier(code)
+	if c == nil {
+		return nil
+	}
+
+	for _, p := range c.Products {
+		if p != nil && p.Product == product {
+			return p
+		}
+	}
+	return nil
+}
+
+// LabelFormat returns a carrier label format or nil
+func (m *MetadataSnapshot) LabelFormat(code CarrierCode, format string) *LabelFormat {
+	c := m.Carrier(code)
+	if c == nil {
+		return nil
+	}
+
+	for _, f := range c.LabelFormats {
+		if f != nil && f.LabelFormat == format {
+			return f
+		}
+	}
+	return nil
+}
+
+// metadataRetryInterval is the time after a failed refresh until the next refresh is attempted (at most the TTL)
+const metadataRetryInterval = time.Minute
+
+// MetadataCache caches the carrier metadata. The metadata is refreshed with a conditional request (If-None-Match) after
+// the TTL has expired. Without an API context the cache serves its snapshot only.
+type MetadataCache struct {
+	api      *APIContext
+	ttl      time.Duration
+	mutex    sync.RWMutex
+	refresh  sync.Mutex
+	snapshot *MetadataSnapshot
+	checked  time.Time
+	failed   time.Time // Time of the last failed refresh
+
+	// OnRefreshError is called if a background refresh fails
+	OnRefreshError func(err error)
+}
+
+// NewMetadataCache creates a metadata cache which is initialized with the embedded default snapshot. api can be nil
+// for offline usage.
+func NewMetadataCache(api *APIContext, ttl time.Duration) *MetadataCache {
+	return &MetadataCache{api: api, ttl: ttl, snapshot: DefaultMetadataSnapshot()}
+}
+
+// Snapshot returns a copy of the cached snapshot without refreshing it
+func (m *MetadataCache) Snapshot() *MetadataSnapshot {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.snapshot.Clone()
+}
+
+// SetSnapshot replaces the cached snapshot (e.g. with a snapshot loaded from disk). nil resets the cache to an empty
+// snapshot.
+func (m *MetadataCache) SetSnapshot(snap *MetadataSnapshot) {
+	if snap == nil {
+		snap = &MetadataSnapshot{}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.snapshot = snap.Clone()
+	m.checked = snap.Fetched
+	m.failed = time.Time{}
+}
+
+// Metadata returns the cached carrier metadata and refreshes it if the TTL has expired. If the refresh fails, the
+// stale metadata is returned together with the error.
+func (m *MetadataCache) Metadata(ctx context.Context) ([]*CarrierMetadata, error) {
+	snap, err := m.Get(ctx)
+	return snap.Carriers, err
+}
+
+// Get returns a copy of the cached snapshot and refreshes it if the TTL has expired. Concurrent calls share one
+// refresh. If the refresh fails, the stale snapshot is returned together with the error and the next refresh is
+// attempted after a minute at the earliest.
+func (m *MetadataCache) Get(ctx context.Context) (*MetadataSnapshot, error) {
+	m.mutex.RLock()
+	expired := m.expired()
+	m.mutex.RUnlock()
+
+	if !expired {
+		return m.Snapshot(), nil
+	}
+
+	err := m.update(ctx, false)
+	return m.Snapshot(), err
+}
+
+// Refresh fetches the metadata from the REST API. The request is skipped by the server if the ETag of the cached
+// snapshot is still valid.
+func (m *MetadataCache) Refresh(ctx context.Context) error {
+	return m.update(ctx, true)
+}
+
+// update fetches the metadata. Without force the request is skipped if another goroutine has refreshed the metadata
+// while waiting for the lock.
+func (m *MetadataCache) update(ctx context.Context, force bool) error {
+	if m.api == nil {
+		return nil
+	}
+
+	m.refresh.Lock()
+	defer m.refresh.Unlock()
+
+	m.mutex.RLock()
+	etag, expired := m.snapshot.ETag, m.expired()
+	m.mutex.RUnlock()
+
+	if !force && !expired {
+		return nil
+	}
+
+	carriers, respETag, err := m.api.MetadataIfNoneMatch(ctx, etag)
+	now := time.Now().UTC()
+
+	if errors.Is(err, ErrNotModified) {
+		m.mutex.Lock()
+		m.checked, m.failed = now, time.Time{}
+		m.mutex.Unlock()
+		return nil
+	}
+
+	if err != nil {
+		m.mutex.Lock()
+		m.failed = now
+		m.mutex.Unlock()
+		return err
+	}
+
+	m.SetSnapshot(&MetadataSnapshot{ETag: respETag, Fetched: now, Carriers: carriers})
+	return nil
+}
+
+// Start refreshes the metadata in the background every TTL until the context is cancelled
+func (m *MetadataCache) Start(ctx context.Context) {
+	if m.api == nil || m.ttl <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.ttl)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Refresh(ctx); err != nil && m.OnRefreshError != nil && ctx.Err() == nil {
+					m.OnRefreshError(err)
+				}
+			}
+		}
+	}()
+}
+
+// expired returns whether the TTL has expired and no refresh has failed within the retry interval. The mutex must be
+// held by the caller.
+func (m *MetadataCache) expired() bool {
+	if m.api == nil {
+		return false
+	}
+
+	retry := metadataRetryInterval
+	if m.ttl < retry {
+		retry = m.ttl
+	}
+	return time.Since(m.checked) >= m.ttl && time.Since(m.failed) >= retry
+}
+
+// MetadataChange is a product or label format that was added or removed
+type MetadataChange struct {
+	Carrier CarrierCode
+	Code    string
+	Name    string
+}
+
+// MetadataDiff contains the differences between two metadata snapshots
+type MetadataDiff struct {
+	AddedProducts       []MetadataChange
+	RemovedProducts     []MetadataChange
+	AddedLabelFormats   []MetadataChange
+	RemovedLabelFormats []MetadataChange
+}
+
+// IsEmpty returns whether both snapshots contain the same products and label formats
+func (m *MetadataDiff) IsEmpty() bool {
+	return len(m.AddedProducts) == 0 && len(m.RemovedProducts) == 0 &&
+		len(m.AddedLabelFormats) == 0 && len(m.RemovedLabelFormats) == 0
+}
+
+// DiffMetadata reports the products and label formats that were added or removed between from and to
+func DiffMetadata(from, to []*CarrierMetadata) *MetadataDiff {
+	oldProducts, oldFormats := metadataIndex(from)
+	newProducts, newFormats := metadataIndex(to)
+
+	d := &MetadataDiff{}
+	d.AddedProducts = metadataSub(newProducts, oldProducts)
+	d.RemovedProducts = metadataSub(oldProducts, newProducts)
+	d.AddedLabelFormats = metadataSub(newFormats, oldFormats)
+	d.RemovedLabelFormats = metadataSub(oldFormats, newFormats)
+	return d
+}
+
+// metadataIndex returns the products and label formats in the order of the metadata
+func metadataIndex(carriers []*CarrierMetadata) (products []MetadataChange, formats []MetadataChange) {
+	for _, c := range carriers {
+		if c == nil {
+			continue
+		}
+
+		for _, p := range c.Products {
+			if p != nil {
+				products = append(products, MetadataChange{Carrier: c.Code, Code: p.Product, Name: p.Name})
+			}
+		}
+
+		for _, f := range c.LabelFormats {
+			if f != nil {
+				formats = append(formats, MetadataChange{Carrier: c.Code, Code: f.LabelFormat, Name: f.Name})
+			}
+		}
+	}
+	return products, formats
+}
+
+// metadataSub returns the changes of a which are not in b
+func metadataSub(a, b []MetadataChange) []MetadataChange {
+	exists := make(map[[2]string]bool, len(b))
+	for _, c := range b {
+		exists[[2]string{string(c.Carrier), c.Code}] = true
+	}
+
+	var res []MetadataChange
+	for _, c := range a {
+		if !exists[[2]string{string(c.Carrier), c.Code}] {
+			res = append(res, c)
+		}
+	}
+	return res
+}
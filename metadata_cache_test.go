@@ -0,0 +1,172 @@
+package shippinglabel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestAPIContext creates an API context for a local test server
+func newTestAPIContext(tb testing.TB, h http.Handler) *APIContext {
+	tb.Helper()
+	srv := httptest.NewServer(h)
+	tb.Cleanup(srv.Close)
+
+	c, err := NewClient("id", "secret")
+	isNoError(tb, err)
+	c.baseURL = srv.URL
+
+	tk := &AuthToken{AccessToken: "token", ExpiresIn: 3600}
+	tk.SetExpirationTime()
+
+	ac, err := c.APIContext(tk)
+	isNoError(tb, err)
+	return ac
+}
+
+// testMetadataSnapshot loads the synthetic metadata fixture. Its products and prices are made up for the tests.
+func testMetadataSnapshot(tb testing.TB) *MetadataSnapshot {
+	tb.Helper()
+	f, err := os.Open("testdata/metadata_fixture.json")
+	isNoError(tb, err)
+	defer f.Close()
+
+	snap, err := LoadMetadataSnapshot(f)
+	isNoError(tb, err)
+	return snap
+}
+
+func TestDefaultMetadataSnapshot(t *testing.T) {
+	snap := DefaultMetadataSnapshot()
+	for _, code := range []CarrierCode{CarrierDHL, CarrierDP, CarrierDPD, CarrierGLS, CarrierHermes, CarrierUPS, CarrierPostAT, CarrierDHLExpress} {
+		c := snap.Carrier(code)
+		if c == nil || len(c.Products) == 0 || len(c.LabelFormats) == 0 {
+			t.Fatalf("missing carrier, products or label formats: %s", code)
+		}
+	}
+	isNotNil(t, snap.Product(CarrierDHL, "V01PAK"))
+	isNotNil(t, snap.LabelFormat(CarrierDHL, "910-300-700"))
+
+	snap = testMetadataSnapshot(t)
+	isNotNil(t, snap.Product(CarrierDHL, "V01PAK"))
+
+	buf := bytes.NewBuffer(nil)
+	isNoError(t, snap.Save(buf))
+	loaded, err := LoadMetadataSnapshot(buf)
+	isNoError(t, err)
+	if !DiffMetadata(snap.Carriers, loaded.Carriers).IsEmpty() {
+		t.Fatalf("expected equal snapshots")
+	}
+}
+
+func TestDiffMetadata(t *testing.T) {
+	from := []*CarrierMetadata{{Code: CarrierDHL, Products: []*Product{{Product: "V01PAK"}, {Product: "V62WP"}}}}
+	to := []*CarrierMetadata{{Code: CarrierDHL, Products: []*Product{{Product: "V01PAK"}}, LabelFormats: []*LabelFormat{{LabelFormat: "A4"}}}}
+
+	d := DiffMetadata(from, to)
+	isEqual(t, []MetadataChange{{Carrier: CarrierDHL, Code: "V62WP"}}, d.RemovedProducts)
+	isEqual(t, []MetadataChange{{Carrier: CarrierDHL, Code: "A4"}}, d.AddedLabelFormats)
+	if len(d.AddedProducts) != 0 || len(d.RemovedLabelFormats) != 0 {
+		t.Fatalf("unexpected diff: %#v", d)
+	}
+}
+
+func TestMetadataCache_Refresh(t *testing.T) {
+	var requests, notModified int
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_ = json.NewEncoder(w).Encode([]*CarrierMetadata{{Code: CarrierGLS}})
+	}))
+
+	cache := NewMetadataCache(api, time.Hour)
+	ctx := context.Background()
+
+	md, err := cache.Metadata(ctx)
+	isNoError(t, err)
+	isEqual(t, 1, len(md))
+	isEqual(t, `"v1"`, cache.Snapshot().ETag)
+
+	// Served from the cache
+	_, err = cache.Metadata(ctx)
+	isNoError(t, err)
+	isEqual(t, 1, requests)
+
+	// Conditional request
+	isNoError(t, cache.Refresh(ctx))
+	isEqual(t, 1, notModified)
+	isEqual(t, CarrierGLS, cache.Snapshot().Carriers[0].Code)
+
+	// Callers cannot change the cache
+	snap, err := cache.Get(ctx)
+	isNoError(t, err)
+	snap.Carriers[0].Code = CarrierDHL
+	isEqual(t, CarrierGLS, cache.Snapshot().Carriers[0].Code)
+}
+
+func TestMetadataCache_Concurrent(t *testing.T) {
+	var requests int32
+	fail := int32(0)
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(20 * time.Millisecond)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(&Error{Message: "unavailable"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]*CarrierMetadata{{Code: CarrierGLS}})
+	}))
+
+	// Expired concurrent calls share one request
+	cache := NewMetadataCache(api, time.Hour)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Get(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	isEqual(t, int32(1), atomic.LoadInt32(&requests))
+
+	// A failed refresh is not retried on every call
+	atomic.StoreInt32(&fail, 1)
+	cache = NewMetadataCache(api, time.Hour)
+	_, err := cache.Get(context.Background())
+	if err == nil {
+		t.Fatalf("expected refresh error")
+	}
+
+	snap, err := cache.Get(context.Background())
+	isNoError(t, err)
+	isNotNil(t, snap.Carrier(CarrierDHL))
+	isEqual(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestMetadataCache_Offline(t *testing.T) {
+	cache := NewMetadataCache(nil, time.Minute)
+	md, err := cache.Metadata(context.Background())
+	isNoError(t, err)
+	isNotNil(t, md)
+
+	cache.SetSnapshot(nil)
+	md, err = cache.Metadata(context.Background())
+	isNoError(t, err)
+	isEqual(t, 0, len(md))
+}
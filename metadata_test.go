@@ -0,0 +1,23 @@
+package shippinglabel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateProduct(t *testing.T) {
+	md := testMetadataSnapshot(t).Carriers
+	isNoError(t, ValidateProduct(md, CarrierDHL, &Product{Product: "V01PAK", UserParticipation: "01", MaxWeight: 20000}))
+
+	if err := ValidateProduct(md, CarrierDHL, &Product{Product: "V01PAK", MaxWeight: 40000}); !errors.Is(err, ErrInvalidProduct) {
+		t.Fatalf("expected invalid product, got %v", err)
+	}
+
+	if err := ValidateProduct(md, CarrierUPS, &Product{Product: "V01PAK"}); !errors.Is(err, ErrUnknownProduct) {
+		t.Fatalf("expected unknown product, got %v", err)
+	}
+
+	if err := ValidateProduct(md, CarrierDHL, nil); !errors.Is(err, ErrRequiredProduct) {
+		t.Fatalf("expected required product, got %v", err)
+	}
+}
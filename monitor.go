@@ -0,0 +1,259 @@
+package shippinglabel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type CarrierEventCode string
+
+const (
+	CarrierEventSecretExpiring CarrierEventCode = "SECRET_EXPIRING"
+	CarrierEventSecretExpired  CarrierEventCode = "SECRET_EXPIRED"
+	CarrierEventVerifyFailed   CarrierEventCode = "VERIFY_FAILED"
+	CarrierEventCheckFailed    CarrierEventCode = "CHECK_FAILED" // ListCarriers failed
+)
+
+// CarrierEvent is raised by the CarrierMonitor
+type CarrierEvent struct {
+	Type       CarrierEventCode
+	Carrier    CarrierCode
+	Name       string
+	Expiration *time.Time
+	Err        error
+	Time       time.Time
+}
+
+// ExpiresIn returns the remaining time until the carrier secret expires
+func (m *CarrierEvent) ExpiresIn() time.Duration {
+	if m.Expiration == nil {
+		return 0
+	}
+	return m.Expiration.Sub(m.Time)
+}
+
+// CarrierEventHandler receives the events of the CarrierMonitor (e.g. to page on-call)
+type CarrierEventHandler interface {
+	HandleCarrierEvent(ctx context.Context, e *CarrierEvent)
+}
+
+// CarrierEventHandlerFunc is an adapter to use ordinary functions as CarrierEventHandler
+type CarrierEventHandlerFunc func(ctx context.Context, e *CarrierEvent)
+
+// HandleCarrierEvent calls f(ctx, e)
+func (f CarrierEventHandlerFunc) HandleCarrierEvent(ctx context.Context, e *CarrierEvent) {
+	f(ctx, e)
+}
+
+// carrierEventKey identifies the events which are deduplicated
+type carrierEventKey struct {
+	Type    CarrierEventCode
+	Carrier CarrierCode
+}
+
+// CarrierMonitor periodically checks the secret expiration of all carriers and verifies their credentials
+type CarrierMonitor struct {
+	api     *APIContext
+	handler CarrierEventHandler
+	now     func() time.Time
+
+	mutex    sync.Mutex
+	notified map[carrierEventKey]*CarrierEvent // Last sent event per type and carrier
+
+	Threshold      time.Duration // Report secrets which expire within the threshold. Default: 14 days
+	CheckInterval  time.Duration // Interval of the expiration check. Default: 1 hour
+	VerifyInterval time.Duration // Interval of VerifyCarrier, 0 disables the verification. Default: 24 hours
+	// Interval after which an unchanged event is sent again, 0 sends it only once. Default: 0
+	RenotifyInterval time.Duration
+}
+
+// NewCarrierMonitor creates a carrier monitor with the default intervals
+func NewCarrierMonitor(api *APIContext, handler CarrierEventHandler) *CarrierMonitor {
+	return &CarrierMonitor{
+		api:            api,
+		handler:        handler,
+		now:            time.Now,
+		Threshold:      14 * 24 * time.Hour,
+		CheckInterval:  time.Hour,
+		VerifyInterval: 24 * time.Hour,
+	}
+}
+
+// Check returns all carriers whose secrets expire within the threshold. The handler receives an event only if the
+// event type or the expiration of the carrier changed since the last notification (or after RenotifyInterval).
+func (m *CarrierMonitor) Check(ctx context.Context) ([]*CarrierEvent, error) {
+	carriers, err := m.listCarriers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.timeNow()
+	events := make([]*CarrierEvent, 0)
+	active := make(map[CarrierCode]bool, len(carriers))
+	for _, c := range carriers {
+		if c == nil || c.UserSecretExpiration == nil {
+			continue
+		}
+
+		e := &CarrierEvent{Carrier: c.Code, Name: c.Name, Expiration: c.UserSecretExpiration, Time: now}
+		switch {
+		case !c.UserSecretExpiration.After(now):
+			e.Type = CarrierEventSecretExpired
+		case c.UserSecretExpiration.Sub(now) <= m.Threshold:
+			e.Type = CarrierEventSecretExpiring
+		default:
+			continue
+		}
+
+		active[c.Code] = true
+		events = append(events, e)
+		m.notify(ctx, e)
+	}
+
+	// Carriers with a renewed secret are reported again when they expire the next time
+	m.reset(func(k carrierEventKey) bool {
+		return (k.Type == CarrierEventSecretExpiring || k.Type == CarrierEventSecretExpired) && !active[k.Carrier]
+	})
+	return events, nil
+}
+
+// notify sends the event unless an event with the same type, carrier and expiration was sent within the
+// RenotifyInterval
+func (m *CarrierMonitor) notify(ctx context.Context, e *CarrierEvent) {
+	m.mutex.Lock()
+	if m.notified == nil {
+		m.notified = make(map[carrierEventKey]*CarrierEvent)
+	}
+
+	key := carrierEventKey{Type: e.Type, Carrier: e.Carrier}
+	last, ok := m.notified[key]
+	if ok && equalTime(last.Expiration, e.Expiration) &&
+		(m.RenotifyInterval <= 0 || e.Time.Sub(last.Time) < m.RenotifyInterval) {
+		m.mutex.Unlock()
+		return
+	}
+
+	m.notified[key] = e
+	m.mutex.Unlock()
+	m.emit(ctx, e)
+}
+
+// reset removes the recorded events whose key matches, they are sent again when they occur the next time
+func (m *CarrierMonitor) reset(match func(k carrierEventKey) bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for k := range m.notified {
+		if match(k) {
+			delete(m.notified, k)
+		}
+	}
+}
+
+// listCarriers lists the carriers and reports a failed check
+func (m *CarrierMonitor) listCarriers(ctx context.Context) ([]*Carrier, error) {
+	carriers, err := m.api.ListCarriers(ctx)
+	if err != nil {
+		m.notify(ctx, &CarrierEvent{Type: CarrierEventCheckFailed, Err: err, Time: m.timeNow()})
+		return nil, err
+	}
+
+	m.reset(func(k carrierEventKey) bool {
+		return k.Type == CarrierEventCheckFailed
+	})
+	return carriers, nil
+}
+
+// Verify runs VerifyCarrier for all carriers and reports the failed verifications. The handler receives a failed
+// verification of a carrier only once until the verification succeeds again (or after RenotifyInterval).
+func (m *CarrierMonitor) Verify(ctx context.Context) ([]*CarrierEvent, error) {
+	carriers, err := m.listCarriers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*CarrierEvent, 0)
+	failed := make(map[CarrierCode]bool, len(carriers))
+	for _, c := range carriers {
+		if c == nil {
+			continue
+		}
+
+		if err = m.api.VerifyCarrier(ctx, c.Code); err != nil {
+			if ctx.Err() != nil {
+				return events, ctx.Err()
+			}
+
+			e := &CarrierEvent{
+				Type:       CarrierEventVerifyFailed,
+				Carrier:    c.Code,
+				Name:       c.Name,
+				Expiration: c.UserSecretExpiration,
+				Err:        err,
+				Time:       m.timeNow(),
+			}
+			failed[c.Code] = true
+			events = append(events, e)
+			m.notify(ctx, e)
+		}
+	}
+
+	m.reset(func(k carrierEventKey) bool {
+		return k.Type == CarrierEventVerifyFailed && !failed[k.Carrier]
+	})
+	return events, nil
+}
+
+// Run checks the carriers immediately and then in the configured intervals until the context is cancelled
+func (m *CarrierMonitor) Run(ctx context.Context) error {
+	checkInterval := m.CheckInterval
+	if checkInterval <= 0 {
+		checkInterval = time.Hour
+	}
+
+	check := time.NewTicker(checkInterval)
+	defer check.Stop()
+
+	var verifyC <-chan time.Time
+	if m.VerifyInterval > 0 {
+		verify := time.NewTicker(m.VerifyInterval)
+		defer verify.Stop()
+		verifyC = verify.C
+		_, _ = m.Verify(ctx)
+	}
+	_, _ = m.Check(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-check.C:
+			_, _ = m.Check(ctx)
+		case <-verifyC:
+			_, _ = m.Verify(ctx)
+		}
+	}
+}
+
+func (m *CarrierMonitor) emit(ctx context.Context, e *CarrierEvent) {
+	if m.handler != nil {
+		m.handler.HandleCarrierEvent(ctx, e)
+	}
+}
+
+// timeNow returns the current time. A zero CarrierMonitor uses time.Now.
+func (m *CarrierMonitor) timeNow() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+// equalTime returns whether both times are nil or equal
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
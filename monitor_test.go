@@ -0,0 +1,138 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCarrierMonitor(t *testing.T) {
+	now := time.Date(2023, 1, 16, 12, 0, 0, 0, time.UTC)
+	expired, expiring, valid := now.Add(-time.Hour), now.Add(48*time.Hour), now.Add(90*24*time.Hour)
+
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/carriers":
+			_ = json.NewEncoder(w).Encode([]*Carrier{
+				{Code: CarrierDHL, UserSecretExpiration: &expired},
+				{Code: CarrierDPD, UserSecretExpiration: &expiring},
+				{Code: CarrierGLS, UserSecretExpiration: &valid},
+				{Code: CarrierUPS},
+			})
+		case "/carriers/DHL/verify":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"invalid credentials"}`))
+		}
+	}))
+
+	var received []*CarrierEvent
+	m := NewCarrierMonitor(api, CarrierEventHandlerFunc(func(ctx context.Context, e *CarrierEvent) {
+		received = append(received, e)
+	}))
+	m.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	events, err := m.Check(ctx)
+	isNoError(t, err)
+	isEqual(t, 2, len(events))
+	isEqual(t, CarrierEventSecretExpired, events[0].Type)
+	isEqual(t, CarrierEventSecretExpiring, events[1].Type)
+	isEqual(t, 48*time.Hour, events[1].ExpiresIn())
+
+	events, err = m.Verify(ctx)
+	isNoError(t, err)
+	isEqual(t, 1, len(events))
+	isEqual(t, CarrierDHL, events[0].Carrier)
+	isEqual(t, 3, len(received))
+}
+
+func TestCarrierMonitor_Notify(t *testing.T) {
+	now := time.Date(2023, 1, 16, 12, 0, 0, 0, time.UTC)
+	expiration := now.Add(48 * time.Hour)
+
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]*Carrier{{Code: CarrierDPD, UserSecretExpiration: &expiration}})
+	}))
+
+	var received []*CarrierEvent
+	m := NewCarrierMonitor(api, CarrierEventHandlerFunc(func(ctx context.Context, e *CarrierEvent) {
+		received = append(received, e)
+	}))
+	m.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		events, err := m.Check(ctx)
+		isNoError(t, err)
+		isEqual(t, 1, len(events))
+	}
+	isEqual(t, 1, len(received))
+
+	// The secret expired in the meantime
+	now = expiration.Add(time.Minute)
+	_, err := m.Check(ctx)
+	isNoError(t, err)
+	isEqual(t, 2, len(received))
+	isEqual(t, CarrierEventSecretExpired, received[1].Type)
+
+	// Unchanged events are repeated after the re-notify interval
+	m.RenotifyInterval = 24 * time.Hour
+	now = now.Add(time.Hour)
+	_, err = m.Check(ctx)
+	isNoError(t, err)
+	isEqual(t, 2, len(received))
+
+	now = now.Add(24 * time.Hour)
+	_, err = m.Check(ctx)
+	isNoError(t, err)
+	isEqual(t, 3, len(received))
+}
+
+func TestCarrierMonitor_Failures(t *testing.T) {
+	var listFails, verifyFails bool
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/carriers" && listFails:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+		case r.URL.Path == "/carriers":
+			_ = json.NewEncoder(w).Encode([]*Carrier{{Code: CarrierDHL}})
+		case verifyFails:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"invalid credentials"}`))
+		}
+	}))
+
+	var received []CarrierEventCode
+	m := &CarrierMonitor{api: api, handler: CarrierEventHandlerFunc(func(ctx context.Context, e *CarrierEvent) {
+		received = append(received, e.Type)
+	})}
+
+	// A zero monitor uses the current time and failed checks are sent once
+	ctx := context.Background()
+	listFails = true
+	for i := 0; i < 2; i++ {
+		if _, err := m.Check(ctx); err == nil {
+			t.Fatalf("expected check error")
+		}
+	}
+	isEqual(t, []CarrierEventCode{CarrierEventCheckFailed}, received)
+
+	listFails, verifyFails = false, true
+	for i := 0; i < 2; i++ {
+		_, err := m.Verify(ctx)
+		isNoError(t, err)
+	}
+	isEqual(t, []CarrierEventCode{CarrierEventCheckFailed, CarrierEventVerifyFailed}, received)
+
+	// A successful verification resets the failure
+	verifyFails = false
+	_, err := m.Verify(ctx)
+	isNoError(t, err)
+	verifyFails = true
+	_, err = m.Verify(ctx)
+	isNoError(t, err)
+	isEqual(t, 3, len(received))
+}
@@ -0,0 +1,168 @@
+package shippinglabel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type PickupPointTypeCode string
+
+const (
+	PickupPointPackstation PickupPointTypeCode = "PACKSTATION" // DHL Packstation
+	PickupPointPostOffice  PickupPointTypeCode = "POST_OFFICE" // DHL Postfiliale or Post AT Postfiliale
+	PickupPointParcelShop  PickupPointTypeCode = "PARCEL_SHOP" // e.g. DPD Pickup, GLS ParcelShop or Hermes PaketShop
+	PickupPointLocker      PickupPointTypeCode = "LOCKER"      // e.g. DPD or GLS parcel locker
+)
+
+// PickupPoint is a parcel locker, post office or parcel shop where the receiver picks up the parcel
+type PickupPoint struct {
+	Type       PickupPointTypeCode
+	LockerID   string // Number of the Packstation or post office or ID of the parcel shop or locker
+	PostNumber string // DHL customer number of the receiver (Postnummer)
+}
+
+var (
+	packstationRegexp = regexp.MustCompile(`(?i)\b(?:pack\s?station|paketstation)\s*(?:nr\.?|no\.?)?\s*(\d{3})\b`)
+	postOfficeRegexp  = regexp.MustCompile(`(?i)\b(?:post\s?filiale|filiale|postamt|post office)\s*(?:nr\.?|no\.?)?\s*(\d{3,4})\b`)
+	parcelShopRegexp  = regexp.MustCompile(`(?i)\b(?:paket\s?shop|parcel\s?shop|pickup\s?(?:point|shop))\s*(?:nr\.?|no\.?|id:?)?\s*([A-Z]{0,3}\d[\w-]*)`)
+	lockerRegexp      = regexp.MustCompile(`(?i)\b(?:parcel\s?locker|paketautomat|locker)\s*(?:nr\.?|no\.?|id:?)?\s*([A-Z]{0,3}\d[\w-]*)`)
+	postNumberRegexp  = regexp.MustCompile(`(?i)(?:^|\D)(\d{6,10})(?:\D|$)`)
+	validPostNumber   = regexp.MustCompile(`^\d{6,10}$`)
+)
+
+// ValidatePostNumber checks the format of a DHL post number (6 to 10 digits)
+func ValidatePostNumber(postNumber string) error {
+	if !validPostNumber.MatchString(postNumber) {
+		return fmt.Errorf("%w: %q", ErrInvalidPostNumber, postNumber)
+	}
+	return nil
+}
+
+// Validate checks the locker ID and the post number
+func (m *PickupPoint) Validate() error {
+	if strings.TrimSpace(m.LockerID) == "" {
+		return fmt.Errorf("%w: locker id is required", ErrInvalidPickupPoint)
+	}
+
+	switch m.Type {
+	case PickupPointPackstation:
+		return ValidatePostNumber(m.PostNumber)
+	case PickupPointPostOffice:
+		if m.PostNumber != "" {
+			return ValidatePostNumber(m.PostNumber)
+		}
+	case PickupPointParcelShop, PickupPointLocker:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidPickupPoint, m.Type)
+	}
+	return nil
+}
+
+// DetectPickupPoint finds free text like "Packstation 123", "Postfiliale 502" or "Paketshop DE1234" in the street and
+// name lines of the address. The post number is taken from the name lines or the AddressAddition.
+func DetectPickupPoint(a *Address) (*PickupPoint, bool) {
+	if a == nil {
+		return nil, false
+	}
+
+	lines := []string{a.Street + " " + a.StreetNumber, a.AddressAddition, a.Company, a.FirstName, a.LastName}
+	for _, d := range []struct {
+		typ    PickupPointTypeCode
+		regexp *regexp.Regexp
+	}{
+		{PickupPointPackstation, packstationRegexp},
+		{PickupPointPostOffice, postOfficeRegexp},
+		{PickupPointParcelShop, parcelShopRegexp},
+		{PickupPointLocker, lockerRegexp},
+	} {
+		for _, line := range lines {
+			m := d.regexp.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+
+			p := &PickupPoint{Type: d.typ, LockerID: m[1]}
+			if d.typ == PickupPointPackstation || d.typ == PickupPointPostOffice {
+				p.PostNumber = findPostNumber(a)
+			}
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// findPostNumber returns the first number with 6 to 10 digits of the name lines or the AddressAddition
+func findPostNumber(a *Address) string {
+	for _, line := range []string{a.AddressAddition, a.FirstName, a.LastName, a.Company} {
+		if m := postNumberRegexp.FindStringSubmatch(line); m != nil && m[1] != a.PostalCode {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// ApplyTo maps the pickup point to the receiver and the carrier services of the shipment. DHL expects Packstations and
+// post offices in the address (e.g. street "Packstation", street number "123" and the post number as address
+// addition, an existing address addition is kept). Parcel shops and lockers of the other carriers and Post AT post
+// offices are sent with a SHOP_DELIVERY service and the home address of the receiver.
+func (m *PickupPoint) ApplyTo(s *Shipment) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	if s.Carrier == nil {
+		return ErrUnknownCarrier
+	}
+
+	if s.Receiver == nil {
+		return ErrRequiredAddress
+	}
+
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	switch s.Carrier.Code {
+	case CarrierDHL:
+		street := "Packstation"
+		switch m.Type {
+		case PickupPointPostOffice, PickupPointParcelShop:
+			street = "Postfiliale"
+		case PickupPointLocker:
+			return fmt.Errorf("%w: %s does not support %s", ErrUnsupportedPickupPoint, s.Carrier.Code, m.Type)
+		}
+
+		// An existing address addition (e.g. a c/o line) is kept
+		r := s.Receiver
+		r.Street, r.StreetNumber = street, m.LockerID
+		switch {
+		case m.PostNumber == "" || strings.Contains(r.AddressAddition, m.PostNumber):
+		case strings.TrimSpace(r.AddressAddition) == "":
+			r.AddressAddition = m.PostNumber
+		default:
+			r.AddressAddition = strings.TrimSpace(r.AddressAddition) + ", " + m.PostNumber
+		}
+		return nil
+	case CarrierDPD, CarrierGLS, CarrierHermes, CarrierUPS, CarrierPostAT:
+		// Only Post AT runs post offices, they are selected like parcel shops
+		if m.Type == PickupPointPackstation || (m.Type == PickupPointPostOffice && s.Carrier.Code != CarrierPostAT) {
+			return fmt.Errorf("%w: %s does not support %s", ErrUnsupportedPickupPoint, s.Carrier.Code, m.Type)
+		}
+
+		svc, err := NewShopDelivery(m.LockerID)
+		if err != nil {
+			return err
+		}
+
+		services := make([]*CarrierService, 0, len(s.Carrier.Services)+1)
+		for _, v := range s.Carrier.Services {
+			if v != nil && v.Service != CarrierServiceShopDelivery {
+				services = append(services, v)
+			}
+		}
+		s.Carrier.Services = append(services, svc)
+		return nil
+	}
+	return fmt.Errorf("%w: %s does not support %s", ErrUnsupportedPickupPoint, s.Carrier.Code, m.Type)
+}
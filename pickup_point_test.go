@@ -0,0 +1,79 @@
+package shippinglabel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectPickupPoint(t *testing.T) {
+	tests := []struct {
+		address  *Address
+		expected *PickupPoint
+	}{
+		{&Address{FirstName: "Max", LastName: "Mustermann", Street: "Packstation 123", AddressAddition: "Postnr. 12345678", PostalCode: "10115"},
+			&PickupPoint{Type: PickupPointPackstation, LockerID: "123", PostNumber: "12345678"}},
+		{&Address{LastName: "Mustermann 987654321", Street: "Packstation", StreetNumber: "456", PostalCode: "10115"},
+			&PickupPoint{Type: PickupPointPackstation, LockerID: "456", PostNumber: "987654321"}},
+		{&Address{LastName: "Mustermann", Street: "Postfiliale 502", PostalCode: "10115"},
+			&PickupPoint{Type: PickupPointPostOffice, LockerID: "502"}},
+		{&Address{LastName: "Mustermann", Street: "Hauptstraße 1", AddressAddition: "Paketshop DE1234"},
+			&PickupPoint{Type: PickupPointParcelShop, LockerID: "DE1234"}},
+	}
+
+	for _, tt := range tests {
+		p, ok := DetectPickupPoint(tt.address)
+		if !ok || *p != *tt.expected {
+			t.Errorf("%+v: expected %+v, got %+v", tt.address, tt.expected, p)
+		}
+	}
+
+	if _, ok := DetectPickupPoint(&Address{Street: "Hauptstraße", StreetNumber: "123"}); ok {
+		t.Errorf("expected no pickup point")
+	}
+}
+
+func TestPickupPoint_ApplyTo(t *testing.T) {
+	p := &PickupPoint{Type: PickupPointPackstation, LockerID: "123", PostNumber: "12345"}
+	s := &Shipment{Carrier: &Carrier{Code: CarrierDHL}, Receiver: &Address{LastName: "Mustermann"}}
+	if err := p.ApplyTo(s); !errors.Is(err, ErrInvalidPostNumber) {
+		t.Fatalf("expected invalid post number, got %v", err)
+	}
+
+	p.PostNumber = "12345678"
+	isNoError(t, p.ApplyTo(s))
+	isEqual(t, s.Receiver.Street, "Packstation")
+	isEqual(t, s.Receiver.StreetNumber, "123")
+	isEqual(t, s.Receiver.AddressAddition, "12345678")
+
+	// The c/o line is kept
+	s.Receiver.AddressAddition = "c/o Schmidt"
+	isNoError(t, p.ApplyTo(s))
+	isEqual(t, s.Receiver.AddressAddition, "c/o Schmidt, 12345678")
+	isNoError(t, p.ApplyTo(s))
+	isEqual(t, s.Receiver.AddressAddition, "c/o Schmidt, 12345678")
+
+	s.Carrier.Code = CarrierDPD
+	if err := p.ApplyTo(s); !errors.Is(err, ErrUnsupportedPickupPoint) {
+		t.Fatalf("expected unsupported pickup point, got %v", err)
+	}
+
+	p = &PickupPoint{Type: PickupPointParcelShop, LockerID: "DE1234"}
+	isNoError(t, p.ApplyTo(s))
+	isEqual(t, len(s.Carrier.Services), 1)
+	isEqual(t, s.Carrier.Services[0].Service, CarrierServiceShopDelivery)
+
+	params, err := s.Carrier.Services[0].TypedParameters()
+	isNoError(t, err)
+	isEqual(t, params.(*ShopDelivery).ParcelShopID, "DE1234")
+
+	s.Carrier = &Carrier{Code: CarrierPostAT}
+	p = &PickupPoint{Type: PickupPointPostOffice, LockerID: "1010"}
+	isNoError(t, p.ApplyTo(s))
+	isEqual(t, len(s.Carrier.Services), 1)
+
+	p.Type = PickupPointPackstation
+	p.PostNumber = "12345678"
+	if err = p.ApplyTo(s); !errors.Is(err, ErrUnsupportedPickupPoint) {
+		t.Fatalf("expected unsupported pickup point, got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package shippinglabel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueueWorkerOptions configures the QueueWorker
+type QueueWorkerOptions struct {
+	Concurrency   int  // Number of parallel shipment creations. Default: 4
+	KeepProcessed bool // Mark processed queue items with a ProcessTime instead of deleting them
+	RetryFailed   bool // Drain also processes queue items which have failed before
+
+	// OnResult is called after each queue item. It may be called concurrently.
+	OnResult func(res *QueueItemResult)
+}
+
+// QueueItemResult is the result of a processed queue item
+type QueueItemResult struct {
+	Item       *ShipmentQueueItem
+	Shipment   *Shipment // Created shipment, nil if the creation failed
+	Err        error     // Set if the creation failed
+	CleanupErr error     // Set if the queue item could not be deleted or updated
+}
+
+// QueueReport summarizes a run of the QueueWorker
+type QueueReport struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int // Queue items which were not started because of a shutdown
+	// Created shipments whose queue item could not be deleted or updated. They are included in Succeeded.
+	CleanupFailed int
+	Duration      time.Duration
+	Results       []*QueueItemResult
+}
+
+// QueueWorker creates shipments from the shipment queue with a bounded worker pool
+type QueueWorker struct {
+	api      *APIContext
+	opts     QueueWorkerOptions
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mutex   sync.Mutex
+	created map[int]*Shipment // Created shipments of queue items whose cleanup failed
+}
+
+// NewQueueWorker creates a queue worker. opts can be nil.
+func NewQueueWorker(api *APIContext, opts *QueueWorkerOptions) *QueueWorker {
+	w := &QueueWorker{api: api, stop: make(chan struct{})}
+	if opts != nil {
+		w.opts = *opts
+	}
+
+	if w.opts.Concurrency <= 0 {
+		w.opts.Concurrency = 4
+	}
+	return w
+}
+
+// Stop stops the worker gracefully: no further queue items are started, running items are finished
+func (w *QueueWorker) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// Drain processes all pending queue items. Processed items and items which have failed before are skipped. Items whose
+// shipment was created, but whose cleanup failed, are only cleaned up.
+func (w *QueueWorker) Drain(ctx context.Context) (*QueueReport, error) {
+	items, err := w.api.ListQueueItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]*ShipmentQueueItem, 0, len(items))
+	for _, item := range items {
+		if item == nil || item.ProcessTime != nil {
+			continue
+		}
+
+		if !w.opts.RetryFailed && queueItemError(item) != nil {
+			continue
+		}
+		pending = append(pending, item)
+	}
+	return w.Process(ctx, pending), nil
+}
+
+// Process creates the shipments of the queue items. Successful items are deleted (or marked as processed), failed
+// items stay in the queue with the error in Shipment.Status.
+func (w *QueueWorker) Process(ctx context.Context, items []*ShipmentQueueItem) *QueueReport {
+	start := time.Now()
+	report := &QueueReport{Total: len(items), Results: make([]*QueueItemResult, len(items))}
+
+	sem := make(chan struct{}, w.opts.Concurrency)
+	wg := sync.WaitGroup{}
+
+dispatch:
+	for i, item := range items {
+		if w.stopped(ctx) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case <-w.stop:
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		// The slot may have been taken after a shutdown
+		if w.stopped(ctx) {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, item *ShipmentQueueItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res := w.processItem(ctx, item)
+			report.Results[i] = res
+			if w.opts.OnResult != nil {
+				w.opts.OnResult(res)
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	results := report.Results[:0]
+	for _, res := range report.Results {
+		switch {
+		case res == nil:
+			report.Skipped++
+			continue
+		case res.Err != nil:
+			report.Failed++
+		default:
+			report.Succeeded++
+		}
+
+		if res.CleanupErr != nil && res.Shipment != nil {
+			report.CleanupFailed++
+		}
+		results = append(results, res)
+	}
+	report.Results = results
+	report.Duration = time.Since(start)
+	return report
+}
+
+// stopped returns whether the worker was stopped or the context was cancelled
+func (w *QueueWorker) stopped(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-w.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// processItem creates the shipment of a queue item and updates the queue. Items which already have a shipment (e.g.
+// because a previous cleanup failed) are not created again, only the cleanup is repeated.
+func (w *QueueWorker) processItem(ctx context.Context, item *ShipmentQueueItem) *QueueItemResult {
+	res := &QueueItemResult{Item: item}
+	switch {
+	case item.Shipment == nil:
+		res.Err = ErrRequiredShipment
+	case w.createdShipment(item.ID) != nil:
+		res.Shipment = w.createdShipment(item.ID)
+	case item.Shipment.ID != 0:
+		res.Shipment = item.Shipment
+	default:
+		res.Shipment, res.Err = w.api.CreateShipment(ctx, item.Shipment)
+		switch {
+		case res.Err == nil && res.Shipment == nil:
+			res.Err = fmt.Errorf("%w: queue item %d", ErrEmptyResponse, item.ID)
+		case res.Err == nil:
+			res.Err = shipmentError(res.Shipment)
+		}
+	}
+
+	if res.Err != nil {
+		// Keep the item in the queue and attach the error
+		if item.Shipment != nil {
+			update := copyQueueItem(item)
+			update.Shipment.Status = &Status{Error: toAPIError(res.Err)}
+			res.CleanupErr = w.api.UpdateQueueItem(ctx, update)
+		}
+		return res
+	}
+
+	w.setCreatedShipment(item.ID, res.Shipment)
+	if w.opts.KeepProcessed {
+		now := time.Now().UTC()
+		update := copyQueueItem(item)
+		update.ProcessTime = &now
+		update.Shipment.ID = res.Shipment.ID
+		update.Shipment.Status = nil
+		res.CleanupErr = w.api.UpdateQueueItem(ctx, update)
+	} else {
+		res.CleanupErr = w.api.DeleteQueueItem(ctx, item.ID)
+	}
+
+	if res.CleanupErr == nil {
+		w.setCreatedShipment(item.ID, nil)
+	}
+	return res
+}
+
+// copyQueueItem copies the queue item and its shipment, so the item of the caller is not changed by an update
+func copyQueueItem(item *ShipmentQueueItem) *ShipmentQueueItem {
+	c := *item
+	s := *item.Shipment
+	c.Shipment = &s
+	return &c
+}
+
+// createdShipment returns the shipment which was created for a queue item whose cleanup failed
+func (w *QueueWorker) createdShipment(id int) *Shipment {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.created[id]
+}
+
+// setCreatedShipment remembers the created shipment of a queue item until its cleanup succeeded. nil removes it.
+func (w *QueueWorker) setCreatedShipment(id int, s *Shipment) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if s == nil {
+		delete(w.created, id)
+		return
+	}
+
+	if w.created == nil {
+		w.created = make(map[int]*Shipment)
+	}
+	w.created[id] = s
+}
+
+// queueItemError returns the error of a failed queue item or nil
+func queueItemError(item *ShipmentQueueItem) *Error {
+	if item.Shipment == nil || item.Shipment.Status == nil {
+		return nil
+	}
+	return item.Shipment.Status.Error
+}
+
+// shipmentError returns the Status.Error of a created shipment
+func shipmentError(s *Shipment) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	if s.Status != nil && s.Status.Error != nil {
+		return s.Status.Error
+	}
+	return nil
+}
+
+// toAPIError converts an error into an *Error
+func toAPIError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return &Error{Message: err.Error()}
+}
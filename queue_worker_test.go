@@ -0,0 +1,125 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestQueueWorker_Drain(t *testing.T) {
+	mutex := sync.Mutex{}
+	deleted, updated := make([]string, 0), make([]*ShipmentQueueItem, 0)
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/shipments/queue":
+			_ = json.NewEncoder(w).Encode([]*ShipmentQueueItem{
+				{ID: 1, Shipment: &Shipment{Reference: "ok"}},
+				{ID: 2, Shipment: &Shipment{Reference: "fail"}},
+				{ID: 3, Shipment: &Shipment{Reference: "ok"}},
+				{ID: 4, Shipment: &Shipment{Reference: "failed before", Status: &Status{Error: &Error{Message: "x"}}}},
+				{ID: 5, Shipment: &Shipment{Reference: "status error"}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/shipments":
+			s := &Shipment{}
+			_ = json.NewDecoder(r.Body).Decode(s)
+			if s.Reference == "fail" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"message":"invalid receiver"}`))
+				return
+			}
+			if s.Reference == "status error" {
+				s.Status = &Status{Error: &Error{Message: "label not created"}}
+			}
+			s.ID = 100
+			_ = json.NewEncoder(w).Encode(s)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		case r.Method == http.MethodPut:
+			item := &ShipmentQueueItem{}
+			_ = json.NewDecoder(r.Body).Decode(item)
+			updated = append(updated, item)
+		}
+	}))
+
+	var callbacks int
+	w := NewQueueWorker(api, &QueueWorkerOptions{Concurrency: 2, OnResult: func(res *QueueItemResult) {
+		mutex.Lock()
+		callbacks++
+		mutex.Unlock()
+	}})
+
+	report, err := w.Drain(context.Background())
+	isNoError(t, err)
+	isEqual(t, 4, report.Total)
+	isEqual(t, 2, report.Succeeded)
+	isEqual(t, 2, report.Failed)
+	isEqual(t, 4, callbacks)
+	isEqual(t, 2, len(deleted))
+	isEqual(t, 2, len(updated))
+	sort.Slice(updated, func(i, j int) bool { return updated[i].ID < updated[j].ID })
+	isEqual(t, "invalid receiver", updated[0].Shipment.Status.Error.Message)
+	isEqual(t, "label not created", updated[1].Shipment.Status.Error.Message)
+
+	// The items of the caller are not changed
+	item := &ShipmentQueueItem{ID: 6, Shipment: &Shipment{Reference: "fail"}}
+	report = w.Process(context.Background(), []*ShipmentQueueItem{item})
+	isEqual(t, 1, report.Failed)
+	if item.Shipment.Status != nil {
+		t.Fatalf("unexpected status: %+v", item.Shipment.Status)
+	}
+
+	// No item is started after a shutdown
+	w.Stop()
+	report = w.Process(context.Background(), []*ShipmentQueueItem{{ID: 5}})
+	isEqual(t, 1, report.Skipped)
+}
+
+func TestQueueWorker_CleanupFailed(t *testing.T) {
+	mutex := sync.Mutex{}
+	var creates, deletes int
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode([]*ShipmentQueueItem{{ID: 1, Shipment: &Shipment{Reference: "ok"}}})
+		case http.MethodPost:
+			creates++
+			_ = json.NewEncoder(w).Encode(&Shipment{ID: 100})
+		case http.MethodDelete:
+			deletes++
+			if deletes == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+			}
+		}
+	}))
+
+	w := NewQueueWorker(api, nil)
+	report, err := w.Drain(context.Background())
+	isNoError(t, err)
+	isEqual(t, 1, report.Succeeded)
+	isEqual(t, 1, report.CleanupFailed)
+	isEqual(t, 100, report.Results[0].Shipment.ID)
+	isNotNil(t, report.Results[0].CleanupErr)
+
+	// The item is still in the queue, but the shipment is not created again
+	report, err = w.Drain(context.Background())
+	isNoError(t, err)
+	isEqual(t, 0, report.CleanupFailed)
+	isEqual(t, 100, report.Results[0].Shipment.ID)
+	isEqual(t, 1, creates)
+	isEqual(t, 2, deletes)
+
+	// Items with a created shipment are skipped
+	report = w.Process(context.Background(), []*ShipmentQueueItem{{ID: 2, Shipment: &Shipment{ID: 200}}})
+	isEqual(t, 1, report.Succeeded)
+	isEqual(t, 1, creates)
+}
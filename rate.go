@@ -0,0 +1,287 @@
+package shippinglabel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProductRule checks whether a carrier product is eligible for a shipment.
+// A non-nil error rejects the product and explains the reason.
+type ProductRule interface {
+	Check(s *Shipment, carrier CarrierCode, p *Product) error
+}
+
+// ProductRuleFunc is an adapter to use ordinary functions as ProductRule
+type ProductRuleFunc func(s *Shipment, carrier CarrierCode, p *Product) error
+
+// Check calls f(s, carrier, p)
+func (f ProductRuleFunc) Check(s *Shipment, carrier CarrierCode, p *Product) error {
+	return f(s, carrier, p)
+}
+
+// ProductCandidate is a carrier product evaluated by the ProductSelector
+type ProductCandidate struct {
+	Carrier    CarrierCode
+	Product    *Product
+	Rejections []error // Reasons why the product was rejected, empty if eligible
+}
+
+// Eligible returns whether the candidate passed all rules
+func (m *ProductCandidate) Eligible() bool {
+	return len(m.Rejections) == 0
+}
+
+// String returns a short description of the candidate and its rejection reasons
+func (m *ProductCandidate) String() string {
+	s := fmt.Sprintf("%s/%s (%.2f)", m.Carrier, m.Product.Product, m.Product.Price)
+	if m.Eligible() {
+		return s
+	}
+
+	reasons := make([]string, 0, len(m.Rejections))
+	for _, err := range m.Rejections {
+		reasons = append(reasons, err.Error())
+	}
+	return s + ": " + strings.Join(reasons, "; ")
+}
+
+// ProductSelector ranks carrier products for a shipment (rate shopping)
+type ProductSelector struct {
+	Rules []ProductRule
+	// Less orders the eligible candidates, the cheapest product wins by default
+	Less func(a, b *ProductCandidate) bool
+}
+
+// NewProductSelector creates a ProductSelector with the default rules (weight, dimensions, international) and the
+// additional rules
+func NewProductSelector(rules ...ProductRule) *ProductSelector {
+	return &ProductSelector{Rules: append(DefaultProductRules(), rules...)}
+}
+
+// DefaultProductRules returns the rules which check the parcels and the destination of a shipment
+func DefaultProductRules() []ProductRule {
+	return []ProductRule{ProductRuleFunc(checkParcelWeight), ProductRuleFunc(checkParcelSize), ProductRuleFunc(checkInternational)}
+}
+
+// Rank evaluates all products of the metadata. The eligible candidates are returned first in order of preference,
+// followed by the rejected candidates.
+func (ps *ProductSelector) Rank(metadata []*CarrierMetadata, s *Shipment) []*ProductCandidate {
+	candidates := make([]*ProductCandidate, 0)
+	for _, md := range metadata {
+		if md == nil {
+			continue
+		}
+
+		for _, p := range md.Products {
+			if p == nil {
+				continue
+			}
+
+			cand := &ProductCandidate{Carrier: md.Code, Product: p}
+			for _, rule := range ps.Rules {
+				if err := rule.Check(s, md.Code, p); err != nil {
+					cand.Rejections = append(cand.Rejections, err)
+				}
+			}
+			candidates = append(candidates, cand)
+		}
+	}
+
+	less := ps.Less
+	if less == nil {
+		less = cheapestProduct
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Eligible() != b.Eligible() {
+			return a.Eligible()
+		}
+		return less(a, b)
+	})
+	return candidates
+}
+
+// Select returns the best eligible candidate. ErrNoEligibleProduct is returned with all rejected candidates if no
+// product passed the rules.
+func (ps *ProductSelector) Select(metadata []*CarrierMetadata, s *Shipment) (*ProductCandidate, []*ProductCandidate, error) {
+	if s == nil {
+		return nil, nil, ErrRequiredShipment
+	}
+
+	candidates := ps.Rank(metadata, s)
+	if len(candidates) == 0 || !candidates[0].Eligible() {
+		return nil, candidates, ErrNoEligibleProduct
+	}
+	return candidates[0], candidates, nil
+}
+
+// SelectProduct returns the cheapest eligible product of all carriers in the metadata
+func SelectProduct(metadata []*CarrierMetadata, s *Shipment, rules ...ProductRule) (*ProductCandidate, error) {
+	cand, _, err := NewProductSelector(rules...).Select(metadata, s)
+	return cand, err
+}
+
+// cheapestProduct orders candidates by price
+func cheapestProduct(a, b *ProductCandidate) bool {
+	return a.Product.Price < b.Product.Price
+}
+
+// RULES
+
+// MaxPrice rejects products which are more expensive than the price
+func MaxPrice(price float64) ProductRule {
+	return ProductRuleFunc(func(s *Shipment, carrier CarrierCode, p *Product) error {
+		if p.Price > price {
+			return fmt.Errorf("price %.2f exceeds the maximum price %.2f", p.Price, price)
+		}
+		return nil
+	})
+}
+
+// ExcludeCarriers rejects the products of the carriers
+func ExcludeCarriers(codes ...CarrierCode) ProductRule {
+	return ProductRuleFunc(func(s *Shipment, carrier CarrierCode, p *Product) error {
+		for _, code := range codes {
+			if code == carrier {
+				return fmt.Errorf("carrier %s is excluded", carrier)
+			}
+		}
+		return nil
+	})
+}
+
+// OnlyCarriers rejects the products of all carriers which are not in the list (e.g. carriers without a contract)
+func OnlyCarriers(codes ...CarrierCode) ProductRule {
+	return ProductRuleFunc(func(s *Shipment, carrier CarrierCode, p *Product) error {
+		for _, code := range codes {
+			if code == carrier {
+				return nil
+			}
+		}
+		return fmt.Errorf("carrier %s is not allowed", carrier)
+	})
+}
+
+// ServiceLookup returns the carrier services which are available for a carrier product
+type ServiceLookup func(carrier CarrierCode, p *Product) []CarrierServiceCode
+
+// RequireServices rejects the products which do not support all services
+func RequireServices(lookup ServiceLookup, services ...CarrierServiceCode) ProductRule {
+	return ProductRuleFunc(func(s *Shipment, carrier CarrierCode, p *Product) error {
+		available := lookup(carrier, p)
+		missing := make([]string, 0)
+		for _, svc := range services {
+			if !contains(available, svc) {
+				missing = append(missing, string(svc))
+			}
+		}
+
+		if len(missing) > 0 {
+			return fmt.Errorf("services not supported: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	})
+}
+
+// TransitTimeLookup returns the delivery time in days of a carrier product. ok is false if the time is unknown.
+type TransitTimeLookup func(carrier CarrierCode, p *Product) (days int, ok bool)
+
+// MaxTransitDays rejects the products which are slower than the days or have an unknown delivery time
+func MaxTransitDays(lookup TransitTimeLookup, days int) ProductRule {
+	return ProductRuleFunc(func(s *Shipment, carrier CarrierCode, p *Product) error {
+		d, ok := lookup(carrier, p)
+		if !ok {
+			return ErrUnknownTransitTime
+		}
+
+		if d > days {
+			return fmt.Errorf("delivery time of %d days exceeds %d days", d, days)
+		}
+		return nil
+	})
+}
+
+func checkParcelWeight(s *Shipment, carrier CarrierCode, p *Product) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	for i, parcel := range s.Parcels {
+		if parcel == nil {
+			return fmt.Errorf("parcel %d: %w", i, ErrRequiredParcel)
+		}
+
+		if parcel.Weight < float64(p.MinWeight) {
+			return fmt.Errorf("parcel %d: weight %g is below the minimum %d", i, parcel.Weight, p.MinWeight)
+		}
+
+		if p.MaxWeight > 0 && parcel.Weight > float64(p.MaxWeight) {
+			return fmt.Errorf("parcel %d: weight %g exceeds the maximum %d", i, parcel.Weight, p.MaxWeight)
+		}
+	}
+	return nil
+}
+
+func checkParcelSize(s *Shipment, carrier CarrierCode, p *Product) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	for i, parcel := range s.Parcels {
+		if parcel == nil {
+			return fmt.Errorf("parcel %d: %w", i, ErrRequiredParcel)
+		}
+
+		dims := []struct {
+			name     string
+			value    float64
+			min, max int
+		}{
+			{"length", parcel.Length, p.MinLength, p.MaxLength},
+			{"width", parcel.Width, p.MinWidth, p.MaxWidth},
+			{"height", parcel.Height, p.MinHeight, p.MaxHeight},
+		}
+
+		for _, d := range dims {
+			if d.value == 0 {
+				continue
+			}
+
+			if d.value < float64(d.min) {
+				return fmt.Errorf("parcel %d: %s %g is below the minimum %d", i, d.name, d.value, d.min)
+			}
+
+			if d.max > 0 && d.value > float64(d.max) {
+				return fmt.Errorf("parcel %d: %s %g exceeds the maximum %d", i, d.name, d.value, d.max)
+			}
+		}
+	}
+	return nil
+}
+
+func checkInternational(s *Shipment, carrier CarrierCode, p *Product) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	if p.IsInternational || s.Sender == nil || s.Receiver == nil {
+		return nil
+	}
+
+	if s.Sender.Country != "" && s.Receiver.Country != "" && !strings.EqualFold(s.Sender.Country, s.Receiver.Country) {
+		return fmt.Errorf("product is not available for international shipments")
+	}
+	return nil
+}
+
+// contains returns whether the slice contains the value
+func contains[T comparable](s []T, v T) bool {
+	for _, t := range s {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package shippinglabel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectProduct(t *testing.T) {
+	metadata := []*CarrierMetadata{
+		{Code: CarrierDHL, Products: []*Product{
+			{Product: "V01PAK", Price: 4.99, MaxWeight: 31500},
+			{Product: "V62WP", Price: 3.49, MaxWeight: 1000},
+		}},
+		{Code: CarrierHermes, Products: []*Product{
+			{Product: "PARCEL", Price: 4.29, MaxWeight: 25000},
+		}},
+		{Code: CarrierGLS, Products: []*Product{
+			{Product: "PARCEL", Price: 3.99, MaxWeight: 40000},
+		}},
+	}
+	s := &Shipment{
+		Parcels:  []*Parcel{{Weight: 2000}},
+		Sender:   &Address{Country: "DE"},
+		Receiver: &Address{Country: "DE"},
+	}
+
+	cand, err := SelectProduct(metadata, s)
+	isNoError(t, err)
+	isEqual(t, CarrierGLS, cand.Carrier)
+
+	cand, err = SelectProduct(metadata, s, ExcludeCarriers(CarrierGLS))
+	isNoError(t, err)
+	isEqual(t, CarrierHermes, cand.Carrier)
+
+	_, candidates, err := NewProductSelector(MaxPrice(3)).Select(metadata, s)
+	isEqual(t, ErrNoEligibleProduct, err)
+	for _, c := range candidates {
+		if c.Eligible() {
+			t.Fatalf("expected rejected candidate: %s", c)
+		}
+	}
+
+	// The lightweight product is rejected because of the price and the weight
+	for _, c := range candidates {
+		if c.Product.Product == "V62WP" && len(c.Rejections) != 2 {
+			t.Fatalf("unexpected candidate: %s", c)
+		}
+	}
+
+	lookup := func(carrier CarrierCode, p *Product) (int, bool) {
+		return 1, carrier != CarrierGLS
+	}
+	cand, candidates, err = NewProductSelector(MaxTransitDays(lookup, 2)).Select(metadata, s)
+	isNoError(t, err)
+	isEqual(t, CarrierHermes, cand.Carrier)
+	for _, c := range candidates {
+		if c.Carrier == CarrierGLS && !errors.Is(c.Rejections[0], ErrUnknownTransitTime) {
+			t.Fatalf("expected unknown delivery time: %s", c)
+		}
+	}
+}
+
+func TestSelectProduct_Nil(t *testing.T) {
+	metadata := []*CarrierMetadata{{Code: CarrierDHL, Products: []*Product{{Product: "V01PAK", Price: 4.99, MaxWeight: 31500}}}}
+
+	if _, err := SelectProduct(metadata, nil); !errors.Is(err, ErrRequiredShipment) {
+		t.Fatalf("expected required shipment, got %v", err)
+	}
+
+	candidates := NewProductSelector().Rank(metadata, nil)
+	isEqual(t, 1, len(candidates))
+	isEqual(t, false, candidates[0].Eligible())
+
+	_, candidates, err := NewProductSelector().Select(metadata, &Shipment{Parcels: []*Parcel{nil}})
+	isEqual(t, ErrNoEligibleProduct, err)
+	if !errors.Is(candidates[0].Rejections[0], ErrRequiredParcel) {
+		t.Fatalf("expected required parcel, got %v", candidates[0].Rejections)
+	}
+}
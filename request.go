@@ -140,6 +140,12 @@ func (r *request) ToJSON(v any) *request {
 	return r.SetAccept(HeaderContentTypeJSON)
 }
 
+// SetResponseHandler sets a custom response handler
+func (r *request) SetResponseHandler(h ResponseHandler) *request {
+	r.respHandler = h
+	return r
+}
+
 // ToBytesBuffer writes the response body to the bytes.Buffer
 func (r *request) ToBytesBuffer(buf *bytes.Buffer) *request {
 	r.respHandler = func(res *http.Response) error {
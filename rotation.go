@@ -0,0 +1,125 @@
+package shippinglabel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// rollbackTimeout limits the rollback, which runs even if the context of the rotation was cancelled
+const rollbackTimeout = 30 * time.Second
+
+// CarrierCredentials are the user credentials of a carrier. The secret is redacted when the credentials are formatted.
+type CarrierCredentials struct {
+	Username   string
+	UserSecret string
+}
+
+// String returns the credentials with a redacted secret
+func (m CarrierCredentials) String() string {
+	return fmt.Sprintf("{Username:%s UserSecret:%s}", m.Username, redact(m.UserSecret))
+}
+
+// GoString returns the credentials with a redacted secret
+func (m CarrierCredentials) GoString() string {
+	return fmt.Sprintf("shippinglabel.CarrierCredentials{Username:%q, UserSecret:%q}", m.Username, redact(m.UserSecret))
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// RotationResult describes the outcome of RotateCarrierCredentials. It contains no secrets.
+type RotationResult struct {
+	Carrier     CarrierCode
+	Rotated     bool // The new credentials are stored and verified
+	RolledBack  bool // The previous credentials were restored after a failed verification or a timed out update
+	UpdateErr   error
+	VerifyErr   error
+	RollbackErr error
+}
+
+// Err returns an error if the new credentials are not active
+func (m *RotationResult) Err() error {
+	if m.Rotated {
+		return nil
+	}
+
+	parts := make([]string, 0, 3)
+	if m.UpdateErr != nil {
+		parts = append(parts, "update: "+m.UpdateErr.Error())
+	}
+	if m.VerifyErr != nil {
+		parts = append(parts, "verify: "+m.VerifyErr.Error())
+	}
+	if m.RollbackErr != nil {
+		parts = append(parts, "rollback: "+m.RollbackErr.Error())
+	}
+	return fmt.Errorf("%w: %s: %s", ErrRotationFailed, m.Carrier, strings.Join(parts, "; "))
+}
+
+// RotateCarrierCredentials updates the credentials of a carrier and verifies them. If the verification fails, the
+// previous credentials are restored. They are also restored if the update timed out or was cancelled, because the
+// server may have applied it. previous can be nil if GetCarrier returns the current secret.
+func (c *APIContext) RotateCarrierCredentials(ctx context.Context, code CarrierCode, next CarrierCredentials, previous *CarrierCredentials) (*RotationResult, error) {
+	if previous == nil {
+		carrier, err := c.GetCarrier(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+
+		if carrier == nil {
+			return nil, fmt.Errorf("%w: carrier %s", ErrEmptyResponse, code)
+		}
+
+		if carrier.UserSecret == "" {
+			return nil, ErrRequiredPreviousCredentials
+		}
+		previous = &CarrierCredentials{Username: carrier.Username, UserSecret: carrier.UserSecret}
+	}
+
+	res := &RotationResult{Carrier: code}
+	if res.UpdateErr = c.UpdateCarrierCredentials(ctx, credentialsCarrier(code, next)); res.UpdateErr != nil {
+		if isTimeout(ctx, res.UpdateErr) {
+			c.rollbackCredentials(res, *previous)
+		}
+		return res, res.Err()
+	}
+
+	if res.VerifyErr = c.VerifyCarrier(ctx, code); res.VerifyErr == nil {
+		res.Rotated = true
+		return res, nil
+	}
+
+	c.rollbackCredentials(res, *previous)
+	return res, res.Err()
+}
+
+// rollbackCredentials restores the previous credentials, even if the context of the rotation was cancelled
+func (c *APIContext) rollbackCredentials(res *RotationResult, previous CarrierCredentials) {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	res.RollbackErr = c.UpdateCarrierCredentials(ctx, credentialsCarrier(res.Carrier, previous))
+	res.RolledBack = res.RollbackErr == nil
+}
+
+// isTimeout returns whether the request was cancelled or timed out, so the outcome on the server is unknown
+func isTimeout(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func credentialsCarrier(code CarrierCode, cred CarrierCredentials) *Carrier {
+	return &Carrier{Code: code, Username: cred.Username, UserSecret: cred.UserSecret}
+}
@@ -0,0 +1,94 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAPIContext_RotateCarrierCredentials(t *testing.T) {
+	var stored []*Carrier
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/carriers/DHL/credentials":
+			c := &Carrier{}
+			_ = json.NewDecoder(r.Body).Decode(c)
+			stored = append(stored, c)
+		case "/carriers/DHL/verify":
+			if stored[len(stored)-1].UserSecret != "valid" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"message":"invalid credentials"}`))
+			}
+		}
+	}))
+
+	ctx := context.Background()
+	previous := &CarrierCredentials{Username: "user", UserSecret: "valid"}
+
+	res, err := api.RotateCarrierCredentials(ctx, CarrierDHL, CarrierCredentials{Username: "user", UserSecret: "wrong"}, previous)
+	if !errors.Is(err, ErrRotationFailed) {
+		t.Fatalf("expected rotation error, got %v", err)
+	}
+	if res.Rotated || !res.RolledBack {
+		t.Fatalf("expected rollback: %+v", res)
+	}
+	isEqual(t, "valid", stored[len(stored)-1].UserSecret)
+
+	res, err = api.RotateCarrierCredentials(ctx, CarrierDHL, CarrierCredentials{Username: "user", UserSecret: "valid"}, previous)
+	isNoError(t, err)
+	if !res.Rotated {
+		t.Fatalf("expected rotation: %+v", res)
+	}
+
+	for _, s := range []string{fmt.Sprint(*previous), fmt.Sprintf("%+v", previous), fmt.Sprintf("%#v", *previous)} {
+		if strings.Contains(s, "valid") {
+			t.Fatalf("secret in output: %s", s)
+		}
+	}
+}
+
+func TestAPIContext_RotateCarrierCredentials_Timeout(t *testing.T) {
+	var stored []string
+	mutex := sync.Mutex{}
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := &Carrier{}
+		_ = json.NewDecoder(r.Body).Decode(c)
+		mutex.Lock()
+		stored = append(stored, c.UserSecret)
+		mutex.Unlock()
+
+		// The update is applied, but the response arrives after the deadline
+		if c.UserSecret == "next" {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	res, err := api.RotateCarrierCredentials(ctx, CarrierDHL, CarrierCredentials{Username: "user", UserSecret: "next"},
+		&CarrierCredentials{Username: "user", UserSecret: "previous"})
+	if !errors.Is(err, ErrRotationFailed) || !res.RolledBack {
+		t.Fatalf("expected rollback, got %+v, %v", res, err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	isEqual(t, []string{"next", "previous"}, stored)
+}
+
+func TestAPIContext_RotateCarrierCredentials_Empty(t *testing.T) {
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("null"))
+	}))
+
+	_, err := api.RotateCarrierCredentials(context.Background(), CarrierDHL, CarrierCredentials{UserSecret: "next"}, nil)
+	if !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("expected empty response, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package shippinglabel
+
+import (
+	"errors"
+	"strings"
+)
+
+// ShipmentCheck is an offline check of a shipment (e.g. CheckServices or AddressValidator.CheckShipment)
+type ShipmentCheck func(s *Shipment) error
+
+// ShipmentCheckError contains the errors of all failed ShipmentChecks. errors.Is and errors.As match every error.
+type ShipmentCheckError struct {
+	Errors []error
+}
+
+func (m *ShipmentCheckError) Error() string {
+	msgs := make([]string, 0, len(m.Errors))
+	for _, err := range m.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m *ShipmentCheckError) Is(target error) bool {
+	for _, err := range m.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ShipmentCheckError) As(target any) bool {
+	for _, err := range m.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckShipment runs all checks and returns a *ShipmentCheckError with the errors of the failed checks
+func CheckShipment(s *Shipment, checks ...ShipmentCheck) error {
+	errs := make([]error, 0)
+	for _, check := range checks {
+		if err := check(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ShipmentCheckError{Errors: errs}
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package shippinglabel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+type SyncActionCode string
+
+const (
+	SyncActionCreate SyncActionCode = "CREATE"
+	SyncActionUpdate SyncActionCode = "UPDATE"
+	SyncActionDelete SyncActionCode = "DELETE"
+)
+
+// SyncOptions configures a synchronization
+type SyncOptions[T any] struct {
+	DryRun      bool             // Only compute the changes
+	Delete      bool             // Delete remote items without a desired item. Otherwise they are kept
+	Concurrency int              // Number of parallel changes. Default: 4
+	Key         func(v T) string // Stable key which matches desired and remote items. Default: see the Sync functions
+}
+
+// SyncChange is a change of a synchronization
+type SyncChange[T any] struct {
+	Action  SyncActionCode
+	Key     string
+	Desired T     // Zero for deletes
+	Current T     // Remote item, zero for creates
+	Err     error // Set if the change failed
+}
+
+// SyncResult contains the changes of a synchronization
+type SyncResult[T any] struct {
+	Changes   []*SyncChange[T] // Ordered by action and key
+	Unchanged int
+}
+
+// Failed returns the changes which could not be applied
+func (m *SyncResult[T]) Failed() []*SyncChange[T] {
+	res := make([]*SyncChange[T], 0)
+	for _, c := range m.Changes {
+		if c.Err != nil {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Syncer reconciles the remote items of a resource with a desired state
+type Syncer[T any] struct {
+	Key    func(v T) string
+	Equal  func(desired, current T) bool
+	ID     func(v T) int
+	SetID  func(v T, id int)
+	List   func(ctx context.Context) ([]T, error)
+	Create func(ctx context.Context, v T) (T, error)
+	Update func(ctx context.Context, v T) error
+	Delete func(ctx context.Context, id int) error
+}
+
+// Sync lists the remote items, matches them with the desired items by key and applies the differences. The IDs of the
+// remote items are set on the desired items unless DryRun is set. opts can be nil. An error is returned if the remote
+// items cannot be listed or a key is not unique, failed changes are reported in the result.
+func (m *Syncer[T]) Sync(ctx context.Context, desired []T, opts *SyncOptions[T]) (*SyncResult[T], error) {
+	o := SyncOptions[T]{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+
+	if o.Key == nil {
+		o.Key = m.Key
+	}
+
+	changes, unchanged, err := m.diff(ctx, desired, o)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SyncResult[T]{Changes: changes, Unchanged: unchanged}
+	if o.DryRun {
+		return res, nil
+	}
+
+	sem := make(chan struct{}, o.Concurrency)
+	wg := sync.WaitGroup{}
+	for _, c := range changes {
+		select {
+		case <-ctx.Done():
+			c.Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(c *SyncChange[T]) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			c.Err = m.apply(ctx, c)
+		}(c)
+	}
+	wg.Wait()
+	return res, nil
+}
+
+// diff computes the changes between the desired and the remote items. The desired items are not changed on a dry run.
+func (m *Syncer[T]) diff(ctx context.Context, desired []T, o SyncOptions[T]) ([]*SyncChange[T], int, error) {
+	key := o.Key
+	want := make(map[string]T, len(desired))
+	for _, v := range desired {
+		k := key(v)
+		if _, ok := want[k]; ok {
+			return nil, 0, fmt.Errorf("%w: %q", ErrDuplicateSyncKey, k)
+		}
+		want[k] = v
+	}
+
+	current, err := m.List(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	changes := make([]*SyncChange[T], 0)
+	matched := make(map[string]bool, len(current))
+	unchanged := 0
+	for _, cur := range current {
+		k := key(cur)
+		d, ok := want[k]
+		if !ok || matched[k] {
+			// Remote duplicates of a key are treated like items without a desired item
+			if o.Delete {
+				changes = append(changes, &SyncChange[T]{Action: SyncActionDelete, Key: k, Current: cur})
+			}
+			continue
+		}
+
+		matched[k] = true
+		if !o.DryRun {
+			m.SetID(d, m.ID(cur))
+		}
+		if m.Equal(d, cur) {
+			unchanged++
+			continue
+		}
+		changes = append(changes, &SyncChange[T]{Action: SyncActionUpdate, Key: k, Desired: d, Current: cur})
+	}
+
+	for k, d := range want {
+		if !matched[k] {
+			changes = append(changes, &SyncChange[T]{Action: SyncActionCreate, Key: k, Desired: d})
+		}
+	}
+
+	order := map[SyncActionCode]int{SyncActionCreate: 0, SyncActionUpdate: 1, SyncActionDelete: 2}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Action != changes[j].Action {
+			return order[changes[i].Action] < order[changes[j].Action]
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, unchanged, nil
+}
+
+func (m *Syncer[T]) apply(ctx context.Context, c *SyncChange[T]) error {
+	switch c.Action {
+	case SyncActionCreate:
+		created, err := m.Create(ctx, c.Desired)
+		if err != nil {
+			return err
+		}
+		m.SetID(c.Desired, m.ID(created))
+		return nil
+	case SyncActionUpdate:
+		return m.Update(ctx, c.Desired)
+	case SyncActionDelete:
+		return m.Delete(ctx, m.ID(c.Current))
+	}
+	return nil
+}
+
+// AddressSyncKey is the default key of SyncAddresses: the type, the name and the location of the address
+func AddressSyncKey(a *Address) string {
+	return syncKey(string(a.AddressType), a.Company, a.FirstName, a.LastName, a.Street, a.StreetNumber, a.PostalCode, a.Country)
+}
+
+// SyncAddresses creates, updates and (with SyncOptions.Delete) deletes addresses to match the desired addresses
+func (c *APIContext) SyncAddresses(ctx context.Context, desired []*Address, opts *SyncOptions[*Address]) (*SyncResult[*Address], error) {
+	s := &Syncer[*Address]{
+		Key: AddressSyncKey,
+		Equal: func(d, cur *Address) bool {
+			a, b := *d, *cur
+			a.ID, b.ID = 0, 0
+			return a == b
+		},
+		ID: func(v *Address) int {
+			if v == nil {
+				return 0
+			}
+			return v.ID
+		},
+		SetID:  func(v *Address, id int) { v.ID = id },
+		List:   c.ListAddresses,
+		Create: c.CreateAddress,
+		Update: c.UpdateAddress,
+		Delete: c.DeleteAddress,
+	}
+	return s.Sync(ctx, desired, opts)
+}
+
+// SyncParcels synchronizes the parcel templates. The default key is the name. IsDefault is not compared, because the
+// default template is chosen by the user.
+func (c *APIContext) SyncParcels(ctx context.Context, desired []*Parcel, opts *SyncOptions[*Parcel]) (*SyncResult[*Parcel], error) {
+	s := &Syncer[*Parcel]{
+		Key: func(v *Parcel) string { return syncKey(v.Name) },
+		Equal: func(d, cur *Parcel) bool {
+			// Custom fields are compared as JSON because decoded numbers are float64
+			a, b := *d, *cur
+			a.ID, b.ID = 0, 0
+			a.IsDefault, b.IsDefault = false, false
+			return jsonEqual(a, b)
+		},
+		ID: func(v *Parcel) int {
+			if v == nil {
+				return 0
+			}
+			return v.ID
+		},
+		SetID:  func(v *Parcel, id int) { v.ID = id },
+		List:   c.ListParcels,
+		Create: c.CreateParcel,
+		Update: c.UpdateParcel,
+		Delete: c.DeleteParcel,
+	}
+	return s.Sync(ctx, desired, opts)
+}
+
+// SyncCSVProfiles synchronizes the CSV profiles. The default key is the name. IsDefault is not compared, because the
+// default profile is chosen by the user.
+func (c *APIContext) SyncCSVProfiles(ctx context.Context, desired []*CSVProfile, opts *SyncOptions[*CSVProfile]) (*SyncResult[*CSVProfile], error) {
+	s := &Syncer[*CSVProfile]{
+		Key: func(v *CSVProfile) string { return syncKey(v.Name) },
+		Equal: func(d, cur *CSVProfile) bool {
+			a, b := *d, *cur
+			a.ID, b.ID = 0, 0
+			a.Created, b.Created = nil, nil
+			a.IsDefault, b.IsDefault = false, false
+			return reflect.DeepEqual(a, b)
+		},
+		ID: func(v *CSVProfile) int {
+			if v == nil {
+				return 0
+			}
+			return v.ID
+		},
+		SetID:  func(v *CSVProfile, id int) { v.ID = id },
+		List:   c.ListCSVProfiles,
+		Create: c.CreateCSVProfile,
+		Update: c.UpdateCSVProfile,
+		Delete: c.DeleteCSVProfile,
+	}
+	return s.Sync(ctx, desired, opts)
+}
+
+// jsonEqual reports whether a and b have the same JSON encoding
+func jsonEqual(a, b any) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	y, err := json.Marshal(b)
+	return err == nil && bytes.Equal(x, y)
+}
+
+// syncKey joins the folded values (e.g. "Hauptstraße" and "hauptstrasse" are equal)
+func syncKey(values ...string) string {
+	for i, v := range values {
+		values[i] = foldName(v)
+	}
+	return strings.Join(values, "|")
+}
+
+// foldName lowercases s and removes diacritics and punctuation (e.g. "Côte d'Ivoire" -> "cote divoire")
+func foldName(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, strings.ReplaceAll(s, "ß", "ss"))
+	if err != nil {
+		folded = s
+	}
+
+	folded = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == '\'' || r == '’':
+			return -1
+		}
+		return ' '
+	}, folded)
+	return strings.Join(strings.Fields(folded), " ")
+}
@@ -0,0 +1,125 @@
+package shippinglabel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAPIContext_SyncAddresses(t *testing.T) {
+	mutex := sync.Mutex{}
+	remote := map[int]*Address{
+		1: {ID: 1, Company: "Shop GmbH", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "10115", City: "Berlin", Country: "DE"},
+		2: {ID: 2, Company: "Lager", Street: "Industriestraße", StreetNumber: "5", PostalCode: "80331", City: "München", Country: "DE"},
+		3: {ID: 3, Company: "Alt", Street: "Weg", StreetNumber: "2", PostalCode: "20095", City: "Hamburg", Country: "DE"},
+	}
+	nextID := 10
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/addresses/"))
+		switch r.Method {
+		case http.MethodGet:
+			list := make([]*Address, 0, len(remote))
+			for i := 1; i < nextID+1; i++ {
+				if a, ok := remote[i]; ok {
+					list = append(list, a)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		case http.MethodPost:
+			a := &Address{}
+			_ = json.NewDecoder(r.Body).Decode(a)
+			a.ID = nextID
+			nextID++
+			remote[a.ID] = a
+			_ = json.NewEncoder(w).Encode(a)
+		case http.MethodPut:
+			a := &Address{}
+			_ = json.NewDecoder(r.Body).Decode(a)
+			remote[id] = a
+		case http.MethodDelete:
+			delete(remote, id)
+		}
+	}))
+
+	desired := []*Address{
+		{Company: "Shop GmbH", Street: "Hauptstraße", StreetNumber: "1", PostalCode: "10115", City: "Berlin", Country: "DE"},
+		{Company: "Lager", Street: "Industriestraße", StreetNumber: "5", PostalCode: "80331", City: "München", Country: "DE", Phone: "+49891234"},
+		{Company: "Retouren", Street: "Ring", StreetNumber: "7", PostalCode: "50667", City: "Köln", Country: "DE", AddressType: AddressTypeReturn},
+	}
+
+	res, err := api.SyncAddresses(context.Background(), desired, &SyncOptions[*Address]{DryRun: true, Delete: true})
+	isNoError(t, err)
+	isEqual(t, res.Unchanged, 1)
+	actions := make([]SyncActionCode, 0, len(res.Changes))
+	for _, c := range res.Changes {
+		actions = append(actions, c.Action)
+	}
+	isEqual(t, actions, []SyncActionCode{SyncActionCreate, SyncActionUpdate, SyncActionDelete})
+	isEqual(t, len(remote), 3)
+	isEqual(t, desired[0].ID, 0)
+
+	res, err = api.SyncAddresses(context.Background(), desired, &SyncOptions[*Address]{Delete: true, Concurrency: 2})
+	isNoError(t, err)
+	isEqual(t, len(res.Failed()), 0)
+	isEqual(t, len(remote), 3)
+	isEqual(t, remote[2].Phone, "+49891234")
+	isEqual(t, desired[2].ID, 10)
+	if _, ok := remote[3]; ok {
+		t.Errorf("expected deleted address")
+	}
+
+	res, err = api.SyncAddresses(context.Background(), desired, nil)
+	isNoError(t, err)
+	isEqual(t, len(res.Changes), 0)
+	isEqual(t, res.Unchanged, 3)
+
+	_, err = api.SyncAddresses(context.Background(), append(desired, &Address{Company: "shop gmbh", Street: "Hauptstrasse", StreetNumber: "1", PostalCode: "10115", Country: "de"}), nil)
+	if !errors.Is(err, ErrDuplicateSyncKey) {
+		t.Errorf("expected duplicate key, got %v", err)
+	}
+}
+
+func TestAPIContext_SyncParcels(t *testing.T) {
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":1,"name":"Small","weight":1.5,"isDefault":true,"customFields":{"size":5}}]`))
+	}))
+
+	// The remote 5 is decoded as float64 and the default template is not compared
+	desired := []*Parcel{{Name: "Small", Weight: 1.5, CustomFields: map[string]any{"size": 5}}}
+	res, err := api.SyncParcels(context.Background(), desired, nil)
+	isNoError(t, err)
+	isEqual(t, len(res.Changes), 0)
+	isEqual(t, res.Unchanged, 1)
+	isEqual(t, desired[0].ID, 1)
+}
+
+func TestAPIContext_SyncCSVProfiles(t *testing.T) {
+	api := newTestAPIContext(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":2,"name":"Shop","delimiter":";","isDefault":true,"created":"2023-01-16T10:30:00Z"}]`))
+	}))
+
+	desired := []*CSVProfile{{Name: "Shop", Delimiter: ";"}}
+	res, err := api.SyncCSVProfiles(context.Background(), desired, nil)
+	isNoError(t, err)
+	isEqual(t, len(res.Changes), 0)
+	isEqual(t, res.Unchanged, 1)
+	isEqual(t, desired[0].ID, 2)
+}
@@ -0,0 +1,101 @@
+package shippinglabel
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations contains the lowercase replacements of characters which cannot be decomposed into Latin-1
+var transliterations = map[rune]string{
+	// Latin
+	'ł': "l", 'đ': "d", 'ħ': "h", 'ı': "i", 'ŀ': "l", 'œ': "oe", 'ŋ': "n", 'ŧ': "t", 'ſ': "s", 'ĸ': "k", 'ĳ': "ij",
+	'ƒ': "f", 'ə': "e",
+
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "e", 'є': "ie", 'ж': "zh", 'з': "z",
+	'и': "i", 'і': "i", 'ї': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ў': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia", 'ђ': "dj", 'ј': "j", 'љ': "lj", 'њ': "nj",
+	'ћ': "c", 'џ': "dz", 'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz",
+
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l",
+	'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f",
+	'χ': "ch", 'ψ': "ps", 'ω': "o",
+
+	// Punctuation
+	'‘': "'", '’': "'", '‚': ",", '“': "\"", '”': "\"", '„': "\"", '–': "-", '—': "-", '‐': "-", '…': "...",
+	'€': "EUR", '•': "-", '\u200b': "",
+}
+
+// Transliterate replaces all characters outside of ISO 8859-1 (Latin-1) with Latin-1 characters (e.g. ł -> l,
+// Москва -> Moskva). Characters which cannot be transliterated are replaced with a question mark.
+func Transliterate(s string) string {
+	if isLatin1(s) {
+		return s
+	}
+
+	b := strings.Builder{}
+	for _, r := range norm.NFC.String(s) {
+		if r <= unicode.MaxLatin1 {
+			b.WriteRune(r)
+			continue
+		}
+
+		lower := unicode.ToLower(r)
+		t, ok := transliterations[lower]
+		if !ok {
+			// Accented Greek and Cyrillic characters (e.g. ή)
+			if decomposed := []rune(norm.NFD.String(string(lower))); len(decomposed) > 1 {
+				t, ok = transliterations[decomposed[0]]
+			}
+		}
+
+		if ok {
+			if lower != r && t != "" {
+				// Keep the case of the first letter (e.g. Ж -> Zh)
+				first, size := utf8.DecodeRuneInString(t)
+				t = string(unicode.ToUpper(first)) + t[size:]
+			}
+			b.WriteString(t)
+			continue
+		}
+
+		// Remove the diacritics which do not exist in Latin-1 (e.g. č -> c, ǘ -> ü)
+		if base, ok := latin1Base(r); ok {
+			b.WriteRune(base)
+			continue
+		}
+		b.WriteRune('?')
+	}
+	return b.String()
+}
+
+// latin1Base returns the decomposed Latin-1 character of r with as many combining marks as possible
+func latin1Base(r rune) (rune, bool) {
+	decomposed := []rune(norm.NFD.String(string(r)))
+	if len(decomposed) == 0 || decomposed[0] > unicode.MaxLatin1 {
+		return 0, false
+	}
+
+	base := decomposed[0]
+	for _, mark := range decomposed[1:] {
+		composed := []rune(norm.NFC.String(string(base) + string(mark)))
+		if len(composed) == 1 && composed[0] <= unicode.MaxLatin1 {
+			base = composed[0]
+		}
+	}
+	return base, true
+}
+
+func isLatin1(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxLatin1 {
+			return false
+		}
+	}
+	return true
+}
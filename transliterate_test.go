@@ -0,0 +1,24 @@
+package shippinglabel
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := map[string]string{
+		"Müller":        "Müller",
+		"Łódź":          "Lódz",
+		"Dvořák":        "Dvorák",
+		"Москва":        "Moskva",
+		"Жуков":         "Zhukov",
+		"Αθήνα":         "Athina",
+		"„Haus“ – Nord": "\"Haus\" - Nord",
+		"Straße 12":     "Straße 12",
+		"東京":            "??",
+		"Schrödinger ǘ": "Schrödinger ü",
+	}
+
+	for s, expected := range tests {
+		if got := Transliterate(s); got != expected {
+			t.Errorf("Transliterate(%q) = %q, expected %q", s, got, expected)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package shippinglabel
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	dateType = reflect.TypeOf(Date{})
+)
+
+// valueFormat contains the formatting options of CSV values
+type valueFormat struct {
+	dateLayout string
+}
+
+// getValueID returns the value of a ValueID path (e.g. Receiver.PostalCode or Parcels.0.Weight). ok is false if the
+// path contains a nil pointer or a missing slice element.
+func getValueID(root reflect.Value, valueID string) (v reflect.Value, ok bool, err error) {
+	v = root
+	for _, part := range strings.Split(valueID, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false, nil
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			idx := fieldIndex(v.Type(), part)
+			if idx < 0 {
+				return reflect.Value{}, false, fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+			}
+			v = v.Field(idx)
+		case reflect.Slice:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 {
+				return reflect.Value{}, false, fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+			}
+
+			if i >= v.Len() {
+				return reflect.Value{}, false, nil
+			}
+			v = v.Index(i)
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false, fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+			}
+
+			v = v.MapIndex(reflect.ValueOf(part).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return reflect.Value{}, false, nil
+			}
+		default:
+			return reflect.Value{}, false, fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+		}
+	}
+	return v, true, nil
+}
+
+// setValueID parses s and sets it at the ValueID path. Nil pointers, slices and maps on the path are created.
+func setValueID(root reflect.Value, valueID string, s string, f valueFormat) error {
+	parts := strings.Split(valueID, ".")
+	v := root
+	for i, part := range parts {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			if v.Type() == timeType || v.Type() == dateType {
+				return fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+			}
+
+			idx := fieldIndex(v.Type(), part)
+			if idx < 0 {
+				return fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+			}
+			v = v.Field(idx)
+		case reflect.Slice:
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 || n > maxSliceIndex {
+				return fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+			}
+
+			if n >= v.Len() {
+				grown := reflect.MakeSlice(v.Type(), n+1, n+1)
+				reflect.Copy(grown, v)
+				v.Set(grown)
+			}
+			v = v.Index(n)
+		case reflect.Map:
+			// Maps are only supported as the last element of the path
+			if i != len(parts)-1 || v.Type().Key().Kind() != reflect.String {
+				return fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+			}
+
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := parseValue(elem, s, f); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(part).Convert(v.Type().Key()), elem)
+			return nil
+		default:
+			return fmt.Errorf("%w: %s", ErrUnknownValueID, valueID)
+		}
+	}
+	return parseValue(v, s, f)
+}
+
+// maxSliceIndex limits the slices which are created by setValueID
+const maxSliceIndex = 999
+
+// fieldIndex returns the index of an exported struct field or -1
+func fieldIndex(t reflect.Type, name string) int {
+	f, ok := t.FieldByName(name)
+	if !ok || len(f.Index) != 1 || !f.IsExported() {
+		return -1
+	}
+	return f.Index[0]
+}
+
+// formatValue converts a value into its CSV representation
+func formatValue(v reflect.Value, f valueFormat) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(f.dateLayout), nil
+	case v.Type() == dateType:
+		d := v.Interface().(Date)
+		if d.IsZero() {
+			return "", nil
+		}
+		return d.Format(f.dateLayout), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("%w: cannot format %s", ErrWrongType, v.Type())
+}
+
+// parseValue parses s into the value. Empty strings leave the value unchanged.
+func parseValue(v reflect.Value, s string, f valueFormat) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == timeType:
+		t, err := time.Parse(f.dateLayout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Type() == dateType:
+		t, err := time.Parse(f.dateLayout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(NewDate(t)))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(s))
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := parseDecimal(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("%w: cannot parse %s", ErrWrongType, v.Type())
+	}
+	return nil
+}
+
+// parseDecimal parses a decimal number with a decimal point or a decimal comma (e.g. 1.5, 1,5 or 1.000,5)
+func parseDecimal(s string, bits int) (float64, error) {
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+	return strconv.ParseFloat(s, bits)
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "1", "true", "yes", "y", "ja", "j", "x":
+		return true, nil
+	case "0", "false", "no", "n", "nein":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean: %q", s)
+}
+
+// valueIDs is the catalogue of all valid CSV Mapping ValueIDs
+var valueIDs = valueIDCatalog(reflect.TypeOf(Shipment{}), "")
+
+// ValueIDs returns a copy of the catalogue of all valid CSV Mapping ValueIDs. Slice elements are listed with the index
+// 0 (e.g. Parcels.0.Weight) and map keys with a * (e.g. AdditionalDetails.*).
+func ValueIDs() []string {
+	return append([]string(nil), valueIDs...)
+}
+
+// excludedValueFields are assigned by the REST API and cannot be imported
+var excludedValueFields = map[string]bool{
+	"ID":                   true,
+	"ShipmentNumber":       true,
+	"Created":              true,
+	"Label":                true,
+	"Status":               true,
+	"IsDefault":            true,
+	"Username":             true,
+	"UserSecret":           true,
+	"UserSecretExpiration": true,
+}
+
+// valueIDCatalog lists the ValueIDs of all exported fields of a struct type
+func valueIDCatalog(t reflect.Type, prefix string) []string {
+	ids := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || excludedValueFields[f.Name] {
+			continue
+		}
+
+		path := prefix + f.Name
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Slice {
+			path += ".0"
+			ft = ft.Elem()
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+		}
+
+		switch {
+		case ft == timeType || ft == dateType:
+			ids = append(ids, path)
+		case ft.Kind() == reflect.Struct:
+			ids = append(ids, valueIDCatalog(ft, path+".")...)
+		case ft.Kind() == reflect.Map:
+			ids = append(ids, path+".*")
+		default:
+			ids = append(ids, path)
+		}
+	}
+	return ids
+}
+
+// normalizeValueID replaces the slice indexes with 0 and the map keys with * to match the catalogue
+func normalizeValueID(id string) string {
+	parts := strings.Split(id, ".")
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil {
+			parts[i] = "0"
+		}
+	}
+
+	norm := strings.Join(parts, ".")
+	for _, valid := range valueIDs {
+		if !strings.HasSuffix(valid, ".*") {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(valid, "*")
+		if strings.HasPrefix(norm, prefix) && len(norm) > len(prefix) && !strings.Contains(norm[len(prefix):], ".") {
+			return valid
+		}
+	}
+	return norm
+}
+
+// IsValidValueID returns whether the ValueID exists in the catalogue. Slice indexes must be between 0 and 999.
+func IsValidValueID(id string) bool {
+	norm := normalizeValueID(id)
+	if !contains(valueIDs, norm) {
+		return false
+	}
+
+	// The map key is not a slice index
+	parts := strings.Split(id, ".")
+	if strings.HasSuffix(norm, ".*") {
+		parts = parts[:len(parts)-1]
+	}
+
+	for _, part := range parts {
+		if n, err := strconv.Atoi(part); err == nil && (n < 0 || n > maxSliceIndex) {
+			return false
+		}
+	}
+	return true
+}
+
+// SuggestValueID returns the catalogue ValueID which is closest to id
+func SuggestValueID(id string) string {
+	norm := strings.ToLower(normalizeValueID(id))
+	best, bestDist := "", -1
+	for _, valid := range valueIDs {
+		d := levenshtein(norm, strings.ToLower(valid))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = valid, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance of two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(v int, values ...int) int {
+	for _, n := range values {
+		if n < v {
+			v = n
+		}
+	}
+	return v
+}
@@ -0,0 +1,410 @@
+package shippinglabel
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// vatFormat is the format and the checksum of the VAT numbers of a member state
+type vatFormat struct {
+	regexp   *regexp.Regexp
+	checksum func(number string) bool // nil if only the format is checked
+}
+
+func newVATFormat(pattern string, checksum func(string) bool) *vatFormat {
+	return &vatFormat{regexp: regexp.MustCompile(`^(?:` + pattern + `)$`), checksum: checksum}
+}
+
+// vatFormats contains the VAT number formats of the EU member states and Northern Ireland (XI). Greece uses EL.
+var vatFormats = map[string]*vatFormat{
+	"AT": newVATFormat(`U\d{8}`, checkVATAT),
+	"BE": newVATFormat(`[01]\d{9}`, checkVATBE),
+	"BG": newVATFormat(`\d{9,10}`, nil),
+	"CY": newVATFormat(`\d{8}[A-Z]`, nil),
+	"CZ": newVATFormat(`\d{8,10}`, nil),
+	"DE": newVATFormat(`\d{9}`, checkMod1110),
+	"DK": newVATFormat(`\d{8}`, func(n string) bool { return weightedSum(n[:8], 2, 7, 6, 5, 4, 3, 2, 1)%11 == 0 }),
+	"EE": newVATFormat(`\d{9}`, checkVATEE),
+	"EL": newVATFormat(`\d{9}`, checkVATEL),
+	"ES": newVATFormat(`[A-Z0-9]\d{7}[A-Z0-9]`, nil),
+	"FI": newVATFormat(`\d{8}`, checkVATFI),
+	"FR": newVATFormat(`[0-9A-HJ-NP-Z]{2}\d{9}`, checkVATFR),
+	"HR": newVATFormat(`\d{11}`, checkMod1110),
+	"HU": newVATFormat(`\d{8}`, checkVATHU),
+	"IE": newVATFormat(`\d{7}[A-W][A-IW]?|\d[A-Z+*]\d{5}[A-W]`, nil),
+	"IT": newVATFormat(`\d{11}`, checkLuhn),
+	"LT": newVATFormat(`\d{9}|\d{12}`, nil),
+	"LU": newVATFormat(`\d{8}`, func(n string) bool { return atoi(n[:6])%89 == atoi(n[6:]) }),
+	"LV": newVATFormat(`\d{11}`, nil),
+	"MT": newVATFormat(`\d{8}`, nil),
+	"NL": newVATFormat(`\d{9}B\d{2}`, checkVATNL),
+	"PL": newVATFormat(`\d{10}`, checkVATPL),
+	"PT": newVATFormat(`\d{9}`, checkVATPT),
+	"RO": newVATFormat(`[1-9]\d{1,9}`, checkVATRO),
+	"SE": newVATFormat(`\d{10}01`, func(n string) bool { return checkLuhn(n[:10]) }),
+	"SI": newVATFormat(`[1-9]\d{7}`, checkVATSI),
+	"SK": newVATFormat(`\d{10}`, func(n string) bool { return mod(n, 11) == 0 }),
+	"XI": newVATFormat(`\d{9}|\d{12}|GD\d{3}|HA\d{3}`, nil),
+}
+
+var (
+	vatCleanRegexp = regexp.MustCompile(`[\s.\-/]`)
+	eoriRegexp     = regexp.MustCompile(`^([A-Z]{2})([A-Z0-9]{1,15})$`)
+)
+
+// NormalizeVATNumber uppercases the VAT number and removes spaces, dots, dashes and slashes
+func NormalizeVATNumber(vat string) string {
+	return strings.ToUpper(vatCleanRegexp.ReplaceAllString(vat, ""))
+}
+
+// IsEUVATNumber returns whether the VAT number has the prefix of an EU member state or Northern Ireland
+func IsEUVATNumber(vat string) bool {
+	vat = NormalizeVATNumber(vat)
+	if len(vat) < 2 {
+		return false
+	}
+	_, ok := vatFormats[vatPrefix(vat[:2])]
+	return ok
+}
+
+// vatPrefix returns the VIES prefix of a country code (EL for Greece)
+func vatPrefix(prefix string) string {
+	if prefix == "GR" {
+		return "EL"
+	}
+	return prefix
+}
+
+// ValidateVATNumber checks the format and, where published, the checksum of an EU VAT number (e.g. DE136695976)
+func ValidateVATNumber(vat string) error {
+	n := NormalizeVATNumber(vat)
+	if len(n) < 3 {
+		return fmt.Errorf("%w: %q", ErrInvalidVATNumber, vat)
+	}
+
+	f, ok := vatFormats[vatPrefix(n[:2])]
+	if !ok {
+		return fmt.Errorf("%w: %q has no EU country prefix", ErrInvalidVATNumber, vat)
+	}
+
+	number := n[2:]
+	if !f.regexp.MatchString(number) {
+		return fmt.Errorf("%w: %q has an invalid format", ErrInvalidVATNumber, vat)
+	}
+
+	if f.checksum != nil && !f.checksum(number) {
+		return fmt.Errorf("%w: %q has an invalid checksum", ErrInvalidVATNumber, vat)
+	}
+	return nil
+}
+
+// ValidateEORI checks the format of an EORI number (country code and up to 15 characters, e.g. DE1234567)
+func ValidateEORI(eori string) error {
+	n := NormalizeVATNumber(eori)
+	m := eoriRegexp.FindStringSubmatch(n)
+	if m == nil || (!IsCountryCode(m[1]) && m[1] != "XI") {
+		return fmt.Errorf("%w: %q", ErrInvalidEORI, eori)
+	}
+
+	switch m[1] {
+	case "DE":
+		if !isDigits(m[2]) {
+			return fmt.Errorf("%w: %q", ErrInvalidEORI, eori)
+		}
+	case "GB", "XI":
+		if len(m[2]) != 12 || !isDigits(m[2]) {
+			return fmt.Errorf("%w: %q", ErrInvalidEORI, eori)
+		}
+	}
+	return nil
+}
+
+// CustomsIDError contains all invalid VAT and EORI numbers of a shipment
+type CustomsIDError struct {
+	Problems []string
+}
+
+func (m *CustomsIDError) Error() string {
+	return "invalid customs identifiers: " + strings.Join(m.Problems, "; ")
+}
+
+// CheckCustomsIDs validates the EU VAT numbers of the sender and the receiver and the customs references as EORI
+// numbers if the shipment needs customs documents. VAT numbers of non-EU countries are not checked. It can be used as
+// ShipmentCheck.
+func CheckCustomsIDs(s *Shipment) error {
+	if s == nil {
+		return ErrRequiredShipment
+	}
+
+	required, err := RequiresCustoms(s.Sender, s.Receiver)
+	if err != nil || !required {
+		return err
+	}
+
+	problems := customsIDProblems(s)
+	if len(problems) > 0 {
+		return &CustomsIDError{Problems: problems}
+	}
+	return nil
+}
+
+func customsIDProblems(s *Shipment) []string {
+	problems := make([]string, 0)
+	for _, a := range []struct {
+		name    string
+		address *Address
+	}{{"sender", s.Sender}, {"receiver", s.Receiver}} {
+		if a.address == nil || a.address.VATNumber == "" || !IsEUVATNumber(a.address.VATNumber) {
+			continue
+		}
+
+		if err := ValidateVATNumber(a.address.VATNumber); err != nil {
+			problems = append(problems, a.name+": "+err.Error())
+		}
+	}
+
+	if c := s.Customs; c != nil {
+		for _, ref := range []struct {
+			name  string
+			value string
+		}{{"sender customs reference", c.SenderCustomsReference}, {"receiver customs reference", c.ReceiverCustomsReference}} {
+			if ref.value == "" {
+				continue
+			}
+
+			if err := ValidateEORI(ref.value); err != nil {
+				problems = append(problems, ref.name+": "+err.Error())
+			}
+		}
+	}
+	return problems
+}
+
+// VATVerification is the result of a remote VAT number verification
+type VATVerification struct {
+	VATNumber string
+	Valid     bool
+	Name      string
+	Address   string
+}
+
+// VATVerifier verifies VAT numbers with a remote service (e.g. VIES). The VAT numbers are normalized and Greek numbers
+// have the VIES prefix EL.
+type VATVerifier interface {
+	VerifyVATNumber(ctx context.Context, vat string) (*VATVerification, error)
+}
+
+// VATVerifierFunc is a function which implements VATVerifier
+type VATVerifierFunc func(ctx context.Context, vat string) (*VATVerification, error)
+
+func (f VATVerifierFunc) VerifyVATNumber(ctx context.Context, vat string) (*VATVerification, error) {
+	return f(ctx, vat)
+}
+
+// CustomsPreflight checks the VAT and EORI numbers of shipments which need customs documents
+type CustomsPreflight struct {
+	Verifier VATVerifier // Optional remote verification of the EU VAT numbers
+}
+
+// Check validates the shipment with CheckCustomsIDs and verifies the valid EU VAT numbers with the Verifier. Errors of
+// the Verifier are returned as they are.
+func (m *CustomsPreflight) Check(ctx context.Context, s *Shipment) error {
+	if err := CheckCustomsIDs(s); err != nil || m.Verifier == nil {
+		return err
+	}
+
+	if required, _ := RequiresCustoms(s.Sender, s.Receiver); !required {
+		return nil
+	}
+
+	problems := make([]string, 0)
+	for _, a := range []struct {
+		name    string
+		address *Address
+	}{{"sender", s.Sender}, {"receiver", s.Receiver}} {
+		if a.address == nil || !IsEUVATNumber(a.address.VATNumber) {
+			continue
+		}
+
+		vat := NormalizeVATNumber(a.address.VATNumber)
+		res, err := m.Verifier.VerifyVATNumber(ctx, vatPrefix(vat[:2])+vat[2:])
+		if err != nil {
+			return err
+		}
+
+		if res == nil || !res.Valid {
+			problems = append(problems, fmt.Sprintf("%s: %v: %q is not registered", a.name, ErrInvalidVATNumber, a.address.VATNumber))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &CustomsIDError{Problems: problems}
+	}
+	return nil
+}
+
+// ShipmentCheck returns Check as ShipmentCheck with the context (e.g. for APIContext.ValidateShipmentWith)
+func (m *CustomsPreflight) ShipmentCheck(ctx context.Context) ShipmentCheck {
+	return func(s *Shipment) error {
+		return m.Check(ctx, s)
+	}
+}
+
+func checkVATAT(n string) bool {
+	sum := 0
+	for i, c := range n[1:8] {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 2
+			d = d/10 + d%10
+		}
+		sum += d
+	}
+	return (10-(sum+4)%10)%10 == int(n[8]-'0')
+}
+
+func checkVATBE(n string) bool {
+	return 97-atoi(n[:8])%97 == atoi(n[8:])
+}
+
+// checkMod1110 checks the ISO 7064 MOD 11,10 check digit (DE, HR)
+func checkMod1110(n string) bool {
+	product := 10
+	for _, c := range n[:len(n)-1] {
+		sum := (int(c-'0') + product) % 10
+		if sum == 0 {
+			sum = 10
+		}
+		product = (2 * sum) % 11
+	}
+
+	check := 11 - product
+	if check == 10 {
+		check = 0
+	}
+	return check == int(n[len(n)-1]-'0')
+}
+
+func checkVATEE(n string) bool {
+	return (10-weightedSum(n[:8], 3, 7, 1, 3, 7, 1, 3, 7)%10)%10 == int(n[8]-'0')
+}
+
+func checkVATEL(n string) bool {
+	return weightedSum(n[:8], 256, 128, 64, 32, 16, 8, 4, 2)%11%10 == int(n[8]-'0')
+}
+
+func checkVATFI(n string) bool {
+	r := weightedSum(n[:7], 7, 9, 10, 5, 8, 4, 2) % 11
+	if r == 1 {
+		return false
+	}
+
+	check := 0
+	if r != 0 {
+		check = 11 - r
+	}
+	return check == int(n[7]-'0')
+}
+
+// checkVATFR checks numeric keys, keys with letters are only checked by format
+func checkVATFR(n string) bool {
+	if !isDigits(n[:2]) {
+		return true
+	}
+	return (12+3*(atoi(n[2:])%97))%97 == atoi(n[:2])
+}
+
+func checkVATHU(n string) bool {
+	return (10-weightedSum(n[:7], 9, 7, 3, 1, 9, 7, 3)%10)%10 == int(n[7]-'0')
+}
+
+// checkVATNL accepts the mod 11 check of the old numbers and the mod 97 check of the numbers since 2020
+func checkVATNL(n string) bool {
+	if r := weightedSum(n[:8], 9, 8, 7, 6, 5, 4, 3, 2) % 11; r != 10 && r == int(n[8]-'0') {
+		return true
+	}
+
+	// N = 23, L = 21, B = 11
+	return mod("2321"+n[:9]+"11"+n[10:], 97) == 1
+}
+
+func checkVATPL(n string) bool {
+	r := weightedSum(n[:9], 6, 5, 7, 2, 3, 4, 5, 6, 7) % 11
+	return r != 10 && r == int(n[9]-'0')
+}
+
+func checkVATPT(n string) bool {
+	check := 11 - weightedSum(n[:8], 9, 8, 7, 6, 5, 4, 3, 2)%11
+	if check > 9 {
+		check = 0
+	}
+	return check == int(n[8]-'0')
+}
+
+func checkVATRO(n string) bool {
+	body := strings.Repeat("0", 10-len(n)) + n[:len(n)-1]
+	check := weightedSum(body, 7, 5, 3, 2, 1, 7, 5, 3, 2) * 10 % 11 % 10
+	return check == int(n[len(n)-1]-'0')
+}
+
+func checkVATSI(n string) bool {
+	check := 11 - weightedSum(n[:7], 8, 7, 6, 5, 4, 3, 2)%11
+	if check == 11 {
+		return false
+	}
+
+	if check == 10 {
+		check = 0
+	}
+	return check == int(n[7]-'0')
+}
+
+// checkLuhn checks the Luhn check digit (IT, SE)
+func checkLuhn(n string) bool {
+	sum := 0
+	for i := 0; i < len(n); i++ {
+		d := int(n[len(n)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func weightedSum(digits string, weights ...int) int {
+	sum := 0
+	for i, c := range digits {
+		sum += int(c-'0') * weights[i]
+	}
+	return sum
+}
+
+// mod returns the remainder of a decimal number of any length
+func mod(digits string, m int) int {
+	r := 0
+	for _, c := range digits {
+		r = (r*10 + int(c-'0')) % m
+	}
+	return r
+}
+
+func atoi(digits string) int {
+	n, _ := strconv.Atoi(digits)
+	return n
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,81 @@
+package shippinglabel
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateVATNumber(t *testing.T) {
+	valid := []string{
+		"DE136695976", "DE 136 695 976", "ATU13585627", "NL004495445B01", "BE0403019261", "FR40303265045",
+		"IT00743110157", "PL8567346215", "DK13585628", "FI20774740", "SE556188840401", "EL094259216", "GR094259216",
+		"PT501964843", "LU15027442", "SI50223054", "HU12892312", "EE100931558", "SK2022749619", "RO18547290",
+		"ESB12345678", "IE6388047V",
+	}
+	for _, vat := range valid {
+		if err := ValidateVATNumber(vat); err != nil {
+			t.Errorf("%s: %v", vat, err)
+		}
+	}
+
+	invalid := []string{"DE136695977", "DE13669597", "ATU13585628", "NL004495446B01", "FR41303265045", "PL8567346216", "CHE123456789", "", "DE"}
+	for _, vat := range invalid {
+		if err := ValidateVATNumber(vat); !errors.Is(err, ErrInvalidVATNumber) {
+			t.Errorf("%s: expected invalid vat number, got %v", vat, err)
+		}
+	}
+}
+
+func TestValidateEORI(t *testing.T) {
+	for _, eori := range []string{"DE1234567", "GB123456789000", "FR12345678901234", "XI123456789000"} {
+		isNoError(t, ValidateEORI(eori))
+	}
+
+	for _, eori := range []string{"DE12345A", "GB123", "ZZ1234567", "1234567", "DE1234567890123456"} {
+		if err := ValidateEORI(eori); !errors.Is(err, ErrInvalidEORI) {
+			t.Errorf("%s: expected invalid eori, got %v", eori, err)
+		}
+	}
+}
+
+func TestCustomsPreflight(t *testing.T) {
+	s := &Shipment{
+		Sender:   &Address{Country: "DE", PostalCode: "10115", VATNumber: "DE136695977"},
+		Receiver: &Address{Country: "CH", PostalCode: "8001", VATNumber: "CHE-123.456.789 MWST"},
+		Customs:  &Customs{SenderCustomsReference: "DE12345A"},
+	}
+
+	var idErr *CustomsIDError
+	if err := CheckCustomsIDs(s); !errors.As(err, &idErr) || len(idErr.Problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", err)
+	}
+
+	s.Sender.VATNumber = "DE136695976"
+	s.Customs.SenderCustomsReference = "DE1234567"
+	isNoError(t, CheckCustomsIDs(s))
+
+	verified := make([]string, 0)
+	p := &CustomsPreflight{Verifier: VATVerifierFunc(func(ctx context.Context, vat string) (*VATVerification, error) {
+		verified = append(verified, vat)
+		return &VATVerification{VATNumber: vat, Valid: false}, nil
+	})}
+	if err := p.Check(context.Background(), s); !errors.As(err, &idErr) {
+		t.Fatalf("expected unregistered vat number, got %v", err)
+	}
+	isEqual(t, verified, []string{"DE136695976"})
+
+	// Greek VAT numbers are verified with the VIES prefix EL
+	greek := *s
+	greek.Sender = &Address{Country: "GR", PostalCode: "10431", VATNumber: "GR 094259216"}
+	verified = verified[:0]
+	if err := p.Check(context.Background(), &greek); !errors.As(err, &idErr) {
+		t.Fatalf("expected unregistered vat number, got %v", err)
+	}
+	isEqual(t, verified, []string{"EL094259216"})
+
+	// Domestic shipments do not need customs
+	s.Receiver = &Address{Country: "DE", PostalCode: "80331"}
+	s.Sender.VATNumber = "DE136695977"
+	isNoError(t, CheckShipment(s, p.ShipmentCheck(context.Background())))
+}